@@ -524,3 +524,52 @@ Blog|HasMenuCurrent: false|Page: Page(/blog/_index.md)
 Blog|IsMenuCurrent: false|Page: Page(/blog/_index.md)
 `)
 }
+
+func TestMenusDepth(t *testing.T) {
+	b := newTestSitesBuilder(t).WithConfigFile("toml", `
+[[menus.main]]
+identifier = "a"
+name = "A"
+weight = 1
+[[menus.main]]
+identifier = "a1"
+name = "A1"
+parent = "a"
+[[menus.main]]
+identifier = "a11"
+name = "A11"
+parent = "a1"
+[[menus.main]]
+identifier = "b"
+name = "B"
+weight = 2
+`)
+
+	b.WithTemplatesAdded("index.html", `
+{{ define "menu" }}{{ range . }}{{ .Name }}:{{ .Depth }}|{{ template "menu" .Children }}{{ end }}{{ end }}
+Menu: {{ template "menu" .Site.Menus.main }}
+`)
+
+	b.Build(BuildCfg{})
+
+	b.AssertFileContent("public/index.html", "Menu: A:0|A1:1|A11:2|B:0|")
+}
+
+func TestMenusParentCycle(t *testing.T) {
+	b := newTestSitesBuilder(t).WithConfigFile("toml", `
+[[menus.main]]
+identifier = "a"
+name = "A"
+parent = "b"
+[[menus.main]]
+identifier = "b"
+name = "B"
+parent = "a"
+`)
+
+	b.WithTemplatesAdded("index.html", `{{ range .Site.Menus.main }}{{ .Name }}|{{ $.HasMenuCurrent "main" . }}{{ end }}`)
+
+	b.BuildFail(BuildCfg{})
+
+	b.Assert(b.H.NumLogErrors(), qt.Equals, 1)
+}
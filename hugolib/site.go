@@ -1494,7 +1494,8 @@ func (s *Site) assembleMenus() {
 		for _, me := range menu {
 			if types.IsNil(me.Page) && me.PageRef != "" {
 				// Try to resolve the page.
-				me.Page, _ = s.getPageNew(nil, me.PageRef)
+				p, _ := s.getPageNew(nil, me.PageRef)
+				me.SetPage(p)
 			}
 			flat[twoD{name, me.KeyName()}] = me
 		}
@@ -1544,8 +1545,25 @@ func (s *Site) assembleMenus() {
 		return false
 	})
 
+	// Check for parent cycles, which would otherwise create
+	// infinite loops when walking the menus.
+	entries := make(map[string]navigation.Menu)
+	for k, e := range flat {
+		entries[k.MenuName] = append(entries[k.MenuName], e)
+	}
+	invalid := make(map[string]bool)
+	for name, menu := range entries {
+		if err := menu.DetectCycles(); err != nil {
+			s.Log.Errorf("menu %q: %s", name, err)
+			invalid[name] = true
+		}
+	}
+
 	// Create Children Menus First
-	for _, e := range flat {
+	for k, e := range flat {
+		if invalid[k.MenuName] {
+			continue
+		}
 		if e.Parent != "" {
 			children[twoD{e.Menu, e.Parent}] = children[twoD{e.Menu, e.Parent}].Add(e)
 		}
@@ -1571,6 +1589,10 @@ func (s *Site) assembleMenus() {
 			s.menus[menu.MenuName] = s.menus[menu.MenuName].Add(e)
 		}
 	}
+
+	for _, menu := range s.menus {
+		menu.InitTree()
+	}
 }
 
 // get any language code to prefix the target file path with.
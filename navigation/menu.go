@@ -16,16 +16,27 @@ package navigation
 import (
 	"fmt"
 	"html/template"
+	"math/rand"
+	"net/url"
+	"reflect"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 
+	"github.com/gohugoio/hugo/common/htime"
 	"github.com/gohugoio/hugo/common/maps"
 	"github.com/gohugoio/hugo/common/types"
 	"github.com/gohugoio/hugo/compare"
+	"github.com/gohugoio/hugo/helpers"
 
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/cast"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
 )
 
 var smc = newMenuCache()
@@ -51,14 +62,58 @@ type MenuEntry struct {
 	// Used to identify this menu entry.
 	Identifier string
 
+	// Used to link this menu entry to the same entry in other languages.
+	TranslationKey string
+
 	title string
 
+	// The LinkTitle of linkTitlePage, cached by cacheLinkTitle.
+	linkTitle     string
+	linkTitlePage Page
+
 	// If set, will be rendered before this menu entry.
 	Pre template.HTML
 
 	// If set, will be rendered after this menu entry.
 	Post template.HTML
 
+	// Screen reader only text, rendered at the start and the end
+	// of the link text by Menu.ToHTML.
+	PreSR  string
+	PostSR string
+
+	// If set, used to compute Pre and Post when rendered, see RenderPre
+	// and RenderPost. Only settable from Go code.
+	PreFunc  func(*MenuEntry) template.HTML
+	PostFunc func(*MenuEntry) template.HTML
+
+	// The target attribute of the link, e.g. "_blank".
+	Target string
+
+	// The rel attribute of the link, e.g. "noopener".
+	Rel string
+
+	// CSS class(es) for this menu entry.
+	Class string
+
+	// Additional URLs, e.g. "/documentation/", this menu entry is considered
+	// active for, see IsActive.
+	Aliases []string
+
+	// Data attributes for the link of this menu entry, with lower case
+	// keys without the "data-" prefix, see DataAttributes.
+	Data map[string]string
+
+	// The name of an icon for this menu entry.
+	// This is independent of Pre and Post, so the two can be combined.
+	Icon string
+
+	// Whether this menu entry is disabled, see Menu.Enabled.
+	Disabled bool
+
+	// Whether this menu entry is hidden, see Menu.Visible.
+	Hidden bool
+
 	// The weight of this menu entry, used for sorting.
 	// Set to a non-zero value, negative or positive.
 	Weight int
@@ -69,11 +124,23 @@ type MenuEntry struct {
 	// Child entries.
 	Children Menu
 
+	// The parent entry and the entries on the same level in the menu tree,
+	// set up by InitTree.
+	parent   *MenuEntry
+	siblings Menu
+
 	// User defined params.
 	Params maps.Params
 }
 
+// URL returns the URL of this menu entry, the RelPermalink of the connected
+// page if set, else the configured URL.
+// A configured URL with a fragment only, e.g. "#intro", is always returned
+// as is, see IsAnchor.
 func (m *MenuEntry) URL() string {
+	if m.IsAnchor() {
+		return m.ConfiguredURL
+	}
 
 	// Check page first.
 	// In Hugo 0.86.0 we added `pageRef`,
@@ -89,10 +156,194 @@ func (m *MenuEntry) URL() string {
 	return m.ConfiguredURL
 }
 
+// RenderPre returns the result of PreFunc if set, else Pre.
+func (m *MenuEntry) RenderPre() template.HTML {
+	if m.PreFunc != nil {
+		return m.PreFunc(m)
+	}
+	return m.Pre
+}
+
+// RenderPost returns the result of PostFunc if set, else Post.
+func (m *MenuEntry) RenderPost() template.HTML {
+	if m.PostFunc != nil {
+		return m.PostFunc(m)
+	}
+	return m.Post
+}
+
+// IsAnchor returns whether this menu entry is configured with a fragment only
+// URL, e.g. "#intro", pointing to a position within the current page.
+func (m *MenuEntry) IsAnchor() bool {
+	return strings.HasPrefix(m.ConfiguredURL, "#")
+}
+
+// SetPage connects the given page to this menu entry, typically when
+// resolving PageRef after the menu entry is created.
+// Note that URL prefers the page's RelPermalink over any ConfiguredURL,
+// which is then only used as a fallback if the page is nil.
+func (m *MenuEntry) SetPage(p Page) {
+	m.Page = p
+	m.cacheLinkTitle()
+}
+
+// cacheLinkTitle caches the LinkTitle of the connected page for Title.
+// This is done when the page is set and when the menu tree is set up, see
+// InitTree, and not lazily in Title, as menu entries are shared between
+// concurrently rendered templates.
+func (m *MenuEntry) cacheLinkTitle() {
+	if types.IsNil(m.Page) {
+		m.linkTitle, m.linkTitlePage = "", nil
+		return
+	}
+	m.linkTitle, m.linkTitlePage = m.Page.LinkTitle(), m.Page
+}
+
+// URLForLang returns the URL with the given language prefix, e.g. "fr",
+// prepended to site relative configured URLs, i.e. URLs starting with a "/",
+// that do not already start with it.
+// The URL of a menu entry connected to a Page already includes any
+// language prefix and is returned as is, as are external and relative URLs.
+func (m *MenuEntry) URLForLang(prefix string) string {
+	u := m.URL()
+	prefix = strings.Trim(prefix, "/")
+
+	if prefix == "" || !types.IsNil(m.Page) || !strings.HasPrefix(u, "/") || strings.HasPrefix(u, "//") {
+		return u
+	}
+
+	prefix = "/" + prefix
+	if u == prefix || strings.HasPrefix(u, prefix+"/") {
+		return u
+	}
+
+	return prefix + u
+}
+
+// IsExternal returns whether this menu entry points to an external resource,
+// i.e. an URL with a scheme (e.g. https, mailto or tel) or a host.
+// Menu entries connected to a Page are never external.
+func (m *MenuEntry) IsExternal() bool {
+	if !types.IsNil(m.Page) {
+		return false
+	}
+
+	u, err := url.Parse(m.ConfiguredURL)
+	if err != nil {
+		return false
+	}
+
+	return u.Scheme != "" || u.Host != ""
+}
+
+// URLHasPrefix returns whether the URL of this menu entry starts with prefix,
+// matching on whole path segments and ignoring trailing slashes, so
+// the prefix "/docs" matches "/docs", "/docs/" and "/docs/intro/", but
+// not "/docsy/". Any query or fragment in the URL is ignored.
+// The prefix "/" matches all root relative URLs.
+func (m *MenuEntry) URLHasPrefix(prefix string) bool {
+	u := stripQueryAndFragment(m.URL())
+	if u == "" || prefix == "" {
+		return false
+	}
+	if prefix == "/" {
+		return strings.HasPrefix(u, "/")
+	}
+	return strings.HasPrefix(strings.TrimSuffix(u, "/")+"/", strings.TrimSuffix(prefix, "/")+"/")
+}
+
+// Host returns the lower cased host name, without any port, of the URL of
+// this menu entry, e.g. "example.org".
+// It returns an empty string for relative and malformed URLs.
+func (m *MenuEntry) Host() string {
+	u, err := url.Parse(m.URL())
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// RelPermalinkWithFragment returns the URL, typically the RelPermalink of the
+// connected page, with the fragment set in the "fragment" param appended,
+// e.g. "/about/#team".
+// The URL is returned as is if it already has a fragment.
+func (m *MenuEntry) RelPermalinkWithFragment() string {
+	u := m.URL()
+	fragment := strings.TrimPrefix(m.ParamString("fragment"), "#")
+	if fragment == "" || strings.Contains(u, "#") {
+		return u
+	}
+	return u + "#" + fragment
+}
+
+// QueryParam returns the first value of the given query parameter in the URL,
+// or an empty string if not found.
+func (m *MenuEntry) QueryParam(key string) string {
+	u, err := url.Parse(m.URL())
+	if err != nil {
+		return ""
+	}
+	return u.Query().Get(key)
+}
+
+// Permalink returns the absolute URL of this menu entry, the Permalink of the
+// connected page if set, else the configured URL if it is absolute,
+// e.g. "https://example.org/", else an empty string.
+// See AbsURL to resolve relative configured URLs.
+func (m *MenuEntry) Permalink() string {
+	if !types.IsNil(m.Page) {
+		return m.Page.Permalink()
+	}
+	u, err := url.Parse(m.ConfiguredURL)
+	if err != nil || !u.IsAbs() {
+		return ""
+	}
+	return m.ConfiguredURL
+}
+
+// AbsURL returns the URL resolved against the given base URL,
+// typically the site's BaseURL.
+// The path of base is treated as a directory, with or without a trailing slash,
+// so relative URLs are resolved below it.
+// URLs that are already absolute (see IsExternal) are returned unchanged.
+func (m *MenuEntry) AbsURL(base string) string {
+	in := m.URL()
+	if in == "" {
+		return ""
+	}
+
+	u, err := url.Parse(in)
+	if err != nil || u.Scheme != "" || u.Host != "" {
+		return in
+	}
+
+	b, err := url.Parse(base)
+	if err != nil {
+		return in
+	}
+	if !strings.HasSuffix(b.Path, "/") {
+		// Resolving against "/docs" would drop the "docs".
+		b.Path += "/"
+	}
+
+	return b.ResolveReference(u).String()
+}
+
+// Fragment returns the fragment part of the URL, i.e. the part after the "#",
+// or an empty string if the URL has no fragment.
+func (m *MenuEntry) Fragment() string {
+	_, fragment, _ := strings.Cut(m.URL(), "#")
+	return fragment
+}
+
 // A narrow version of page.Page.
+// All page.Page implementations satisfy it; other implementations,
+// e.g. in tests, must implement all of its methods, including Date and
+// Permalink.
 type Page interface {
 	LinkTitle() string
 	RelPermalink() string
+	Permalink() string
 	Path() string
 	Section() string
 	Weight() int
@@ -100,6 +351,7 @@ type Page interface {
 	IsSection() bool
 	IsAncestor(other any) (bool, error)
 	Params() maps.Params
+	Date() time.Time
 }
 
 // Menu is a collection of menu entries.
@@ -111,11 +363,148 @@ type Menus map[string]Menu
 // PageMenus is a dictionary of menus defined in the Pages.
 type PageMenus map[string]*MenuEntry
 
+// Merge returns a new set of menus with the menus in both m and other.
+// For menus present in both, the entries in other not already in m
+// (see MenuEntry.IsEqual) are added, so entries in m take precedence.
+// The merged menus are sorted, see Menu.Sort.
+func (m Menus) Merge(other Menus) Menus {
+	merged := make(Menus)
+	for name, menu := range m {
+		merged[name] = menu.Clone()
+	}
+
+	for name, menu := range other {
+		existing := m[name]
+	OUTER:
+		for _, me := range menu {
+			for _, e := range existing {
+				if e.IsEqual(me) {
+					continue OUTER
+				}
+			}
+			merged[name] = append(merged[name], me)
+		}
+	}
+
+	for _, menu := range merged {
+		menu.Sort()
+	}
+
+	return merged
+}
+
+// NamedMenu is a menu and its name.
+type NamedMenu struct {
+	Name string
+	Menu Menu
+}
+
+// Keys returns the menu names sorted alphabetically.
+func (m Menus) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Names returns the menu names sorted alphabetically.
+// This is the same as Keys.
+func (m Menus) Names() []string {
+	return m.Keys()
+}
+
+// Sorted returns the menus sorted by name.
+func (m Menus) Sorted() []NamedMenu {
+	sorted := make([]NamedMenu, 0, len(m))
+	for _, k := range m.Keys() {
+		sorted = append(sorted, NamedMenu{Name: k, Menu: m[k]})
+	}
+	return sorted
+}
+
+// Get returns the menu with the given name, ignoring case, or nil if not found.
+// The menu names are stored as defined, but looked up case insensitively,
+// so both "Main" and "main" will find a menu named "main".
+// An exact match wins; if there are only menus with names differing in case,
+// e.g. "Main" and "MAIN", the first in sort order (see Keys) is returned.
+func (m Menus) Get(name string) Menu {
+	menu, _ := m.lookup(name)
+	return menu
+}
+
+// Has returns whether there is a menu with the given name, ignoring case,
+// see Get.
+func (m Menus) Has(name string) bool {
+	_, found := m.lookup(name)
+	return found
+}
+
+func (m Menus) lookup(name string) (Menu, bool) {
+	if menu, found := m[name]; found {
+		return menu, true
+	}
+	for _, k := range m.Keys() {
+		if strings.EqualFold(k, name) {
+			return m[k], true
+		}
+	}
+	return nil, false
+}
+
 // HasChildren returns whether this menu item has any children.
 func (m *MenuEntry) HasChildren() bool {
 	return m.Children != nil
 }
 
+// ChildCount returns the number of direct children of this menu entry.
+func (m *MenuEntry) ChildCount() int {
+	return len(m.Children)
+}
+
+// HasGrandchildren returns whether any of the children of this menu entry
+// has children.
+func (m *MenuEntry) HasGrandchildren() bool {
+	for _, child := range m.Children {
+		if child.HasChildren() {
+			return true
+		}
+	}
+	return false
+}
+
+// PathSegments returns the non-empty segments of the PageRef, or of the Path of
+// the connected page if PageRef is not set, e.g. ["docs", "intro"] for "/docs/intro".
+func (m *MenuEntry) PathSegments() []string {
+	p := m.PageRef
+	if p == "" && !types.IsNil(m.Page) {
+		p = m.Page.Path()
+	}
+
+	segments := []string{}
+	for _, s := range strings.Split(p, "/") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+	return segments
+}
+
+// EffectiveWeight returns the Weight if set, else the weight of the connected
+// page, if any.
+func (m *MenuEntry) EffectiveWeight() int {
+	if m.Weight != 0 || types.IsNil(m.Page) {
+		return m.Weight
+	}
+	return m.Page.Weight()
+}
+
+// HasIcon returns whether this menu entry has an icon set.
+func (m *MenuEntry) HasIcon() bool {
+	return m.Icon != ""
+}
+
 // KeyName returns the key used to identify this menu entry.
 func (m *MenuEntry) KeyName() string {
 	if m.Identifier != "" {
@@ -139,14 +528,92 @@ func (m *MenuEntry) IsEqual(inme *MenuEntry) bool {
 	return m.hopefullyUniqueID() == inme.hopefullyUniqueID() && m.Parent == inme.Parent
 }
 
+// DeepEqual returns whether the two menu entries are equal, see IsEqual,
+// and also have the same name, weight, URL, identifier and params.
+func (m *MenuEntry) DeepEqual(inme *MenuEntry) bool {
+	if !m.IsEqual(inme) {
+		return false
+	}
+
+	if m.Name != inme.Name ||
+		m.Weight != inme.Weight ||
+		m.ConfiguredURL != inme.ConfiguredURL ||
+		m.Identifier != inme.Identifier {
+		return false
+	}
+
+	if len(m.Params) != len(inme.Params) {
+		return false
+	}
+	for k, v := range m.Params {
+		vv, found := inme.Params[k]
+		if !found || !reflect.DeepEqual(v, vv) {
+			return false
+		}
+	}
+
+	return true
+}
+
 // IsSameResource returns whether the two menu entries points to the same
-// resource (URL).
+// resource (URL), also considering their Aliases.
 func (m *MenuEntry) IsSameResource(inme *MenuEntry) bool {
 	if m.isSamePage(inme.Page) {
 		return m.Page == inme.Page
 	}
-	murl, inmeurl := m.URL(), inme.URL()
-	return murl != "" && inmeurl != "" && murl == inmeurl
+	return m.hasSameURL(inme, nil)
+}
+
+// IsSameResourceNormalized is like IsSameResource, but ignores trailing
+// slashes and case in the URL path, so "/about" and "/About/" are
+// considered the same resource.
+func (m *MenuEntry) IsSameResourceNormalized(inme *MenuEntry) bool {
+	if m.isSamePage(inme.Page) {
+		return m.Page == inme.Page
+	}
+	return m.hasSameURL(inme, normalizeMenuURL)
+}
+
+// hasSameURL returns whether any of the URL and Aliases of m and inme
+// are equal, after applying normalize, if set.
+func (m *MenuEntry) hasSameURL(inme *MenuEntry, normalize func(string) string) bool {
+	for _, u1 := range m.urls() {
+		for _, u2 := range inme.urls() {
+			if normalize != nil && normalize(u1) == normalize(u2) || u1 == u2 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// urls returns the URL followed by the Aliases of this menu entry,
+// skipping empty values.
+func (m *MenuEntry) urls() []string {
+	var urls []string
+	if u := m.URL(); u != "" {
+		urls = append(urls, u)
+	}
+	for _, alias := range m.Aliases {
+		if alias != "" {
+			urls = append(urls, alias)
+		}
+	}
+	return urls
+}
+
+// normalizeMenuURL lower cases the path of s and removes any trailing slash.
+func normalizeMenuURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return strings.TrimSuffix(strings.ToLower(s), "/")
+	}
+	u.Path = strings.ToLower(u.Path)
+	if len(u.Path) > 1 {
+		u.Path = strings.TrimSuffix(u.Path, "/")
+	}
+	u.RawPath = ""
+	return u.String()
 }
 
 func (m *MenuEntry) isSamePage(p Page) bool {
@@ -156,6 +623,108 @@ func (m *MenuEntry) isSamePage(p Page) bool {
 	return false
 }
 
+// IsActive returns whether this menu entry represents the given page,
+// either by being connected to it or by pointing to its URL, directly or
+// through one of its Aliases.
+func (m *MenuEntry) IsActive(p Page) bool {
+	if types.IsNil(p) {
+		return false
+	}
+	if m.isSamePage(p) {
+		return true
+	}
+	permalink := p.RelPermalink()
+	for _, u := range m.urls() {
+		if u == permalink {
+			return true
+		}
+	}
+	return false
+}
+
+// IsActiveIgnoringQuery is like IsActive, but ignores any query string and
+// fragment in the URLs compared, so "/search/?q=hugo" is active for a page
+// with the RelPermalink "/search/".
+func (m *MenuEntry) IsActiveIgnoringQuery(p Page) bool {
+	if types.IsNil(p) {
+		return false
+	}
+	if m.isSamePage(p) {
+		return true
+	}
+	permalink := stripQueryAndFragment(p.RelPermalink())
+	for _, u := range m.urls() {
+		if u = stripQueryAndFragment(u); u != "" && u == permalink {
+			return true
+		}
+	}
+	return false
+}
+
+// stripQueryAndFragment returns s without anything from the first "?" or "#".
+func stripQueryAndFragment(s string) string {
+	if i := strings.IndexAny(s, "?#"); i != -1 {
+		return s[:i]
+	}
+	return s
+}
+
+// HasActiveChild returns whether any of the descendants of this menu entry
+// is active for the given page, see IsActive.
+func (m *MenuEntry) HasActiveChild(p Page) bool {
+	var found bool
+	m.Children.walk(func(me *MenuEntry, depth int) bool {
+		found = me.IsActive(p)
+		return !found
+	})
+	return found
+}
+
+// AriaCurrent returns the value to use for the aria-current attribute
+// of this menu entry's link: "page" if the entry is active for p,
+// "true" if any of its descendants is, else an empty string.
+func (m *MenuEntry) AriaCurrent(p Page) string {
+	if m.IsActive(p) {
+		return "page"
+	}
+	if m.HasActiveChild(p) {
+		return "true"
+	}
+	return ""
+}
+
+// IsAncestorOf returns whether the page connected to this menu entry
+// is an ancestor of the given page.
+// It returns false for menu entries not connected to a page.
+func (m *MenuEntry) IsAncestorOf(p Page) (bool, error) {
+	if types.IsNil(m.Page) || types.IsNil(p) {
+		return false, nil
+	}
+	return m.Page.IsAncestor(p)
+}
+
+// InSection returns whether this menu entry is connected to a page in the same
+// section as the given page.
+// It returns false for menu entries not connected to a page.
+func (m *MenuEntry) InSection(p Page) bool {
+	if types.IsNil(m.Page) || types.IsNil(p) {
+		return false
+	}
+	return m.Page.Section() == p.Section()
+}
+
+// IsPage returns whether this menu entry is connected to a regular page.
+// It returns false for menu entries not connected to a page.
+func (m *MenuEntry) IsPage() bool {
+	return !types.IsNil(m.Page) && m.Page.IsPage()
+}
+
+// IsSection returns whether this menu entry is connected to a section page.
+// It returns false for menu entries not connected to a page.
+func (m *MenuEntry) IsSection() bool {
+	return !types.IsNil(m.Page) && m.Page.IsSection()
+}
+
 // For internal use.
 func (m *MenuEntry) MarshallMap(ime map[string]any) error {
 	var err error
@@ -176,6 +745,41 @@ func (m *MenuEntry) MarshallMap(ime map[string]any) error {
 			m.Pre = template.HTML(cast.ToString(v))
 		case "post":
 			m.Post = template.HTML(cast.ToString(v))
+		case "presr":
+			m.PreSR = cast.ToString(v)
+		case "postsr":
+			m.PostSR = cast.ToString(v)
+		case "target":
+			m.Target = cast.ToString(v)
+		case "rel":
+			m.Rel = cast.ToString(v)
+		case "class":
+			m.Class = cast.ToString(v)
+		case "translationkey":
+			m.TranslationKey = cast.ToString(v)
+		case "icon":
+			m.Icon = cast.ToString(v)
+		case "aliases":
+			aliases, cerr := cast.ToStringSliceE(v)
+			if cerr != nil {
+				err = fmt.Errorf("cannot convert %T to aliases", v)
+				break
+			}
+			m.Aliases = aliases
+		case "data":
+			data, cerr := cast.ToStringMapStringE(v)
+			if cerr != nil {
+				err = fmt.Errorf("cannot convert %T to data attributes", v)
+				break
+			}
+			m.Data = make(map[string]string, len(data))
+			for dk, dv := range data {
+				m.Data[strings.ToLower(dk)] = dv
+			}
+		case "disabled":
+			m.Disabled = cast.ToBool(v)
+		case "hidden":
+			m.Hidden = cast.ToBool(v)
 		case "identifier":
 			m.Identifier = cast.ToString(v)
 		case "parent":
@@ -204,6 +808,22 @@ func (m Menu) Add(me *MenuEntry) Menu {
 	return m
 }
 
+// Append returns a new menu with entries added after the entries in m.
+// Unlike Add, m is not modified and the menu is not sorted.
+func (m Menu) Append(entries ...*MenuEntry) Menu {
+	menu := make(Menu, 0, len(m)+len(entries))
+	menu = append(menu, m...)
+	return append(menu, entries...)
+}
+
+// Prepend returns a new menu with entries added before the entries in m.
+// Unlike Add, m is not modified and the menu is not sorted.
+func (m Menu) Prepend(entries ...*MenuEntry) Menu {
+	menu := make(Menu, 0, len(m)+len(entries))
+	menu = append(menu, entries...)
+	return append(menu, m...)
+}
+
 /*
  * Implementation of a custom sorter for Menu
  */
@@ -225,24 +845,31 @@ func (by menuEntryBy) Sort(menu Menu) {
 	sort.Stable(ms)
 }
 
-var defaultMenuEntrySort = func(m1, m2 *MenuEntry) bool {
-	if m1.Weight == m2.Weight {
-		c := compare.Strings(m1.Name, m2.Name)
-		if c == 0 {
-			return m1.Identifier < m2.Identifier
+var defaultMenuEntrySort = menuEntrySortByWeight(func(m *MenuEntry) int { return m.Weight })
+
+// menuEntrySortByWeight creates a sort function ordering by the weight returned
+// by weight, name and then identifier. Entries with no weight are sorted last.
+func menuEntrySortByWeight(weight func(m *MenuEntry) int) func(m1, m2 *MenuEntry) bool {
+	return func(m1, m2 *MenuEntry) bool {
+		w1, w2 := weight(m1), weight(m2)
+		if w1 == w2 {
+			c := compare.Strings(m1.Name, m2.Name)
+			if c == 0 {
+				return m1.Identifier < m2.Identifier
+			}
+			return c < 0
 		}
-		return c < 0
-	}
 
-	if m2.Weight == 0 {
-		return true
-	}
+		if w2 == 0 {
+			return true
+		}
 
-	if m1.Weight == 0 {
-		return false
-	}
+		if w1 == 0 {
+			return false
+		}
 
-	return m1.Weight < m2.Weight
+		return w1 < w2
+	}
 }
 
 func (ms *menuSorter) Len() int      { return len(ms.menu) }
@@ -252,11 +879,59 @@ func (ms *menuSorter) Swap(i, j int) { ms.menu[i], ms.menu[j] = ms.menu[j], ms.m
 func (ms *menuSorter) Less(i, j int) bool { return ms.by(ms.menu[i], ms.menu[j]) }
 
 // Sort sorts the menu by weight, name and then by identifier.
+// The menu is sorted in place, use ByWeight to get a sorted copy
+// of a menu that may be shared.
 func (m Menu) Sort() Menu {
 	menuEntryBy(defaultMenuEntrySort).Sort(m)
 	return m
 }
 
+// SortFunc sorts the menu in place using the given less function and
+// returns the menu.
+// The sort is stable, entries considered equal keep their order.
+// Do not use it on menus that may be shared, i.e. the site menus, the
+// children of their entries, or the results of ByWeight and the other sort
+// methods, which are cached and returned to every caller; sort a Clone, or
+// use ThenBy, which sorts a copy, instead.
+func (m Menu) SortFunc(less func(a, b *MenuEntry) bool) Menu {
+	menuEntryBy(less).Sort(m)
+	return m
+}
+
+// ThenBy returns a copy of the sorted menu m with the entries that are equal
+// by the sort order of m sorted using the given less functions, in order, e.g.
+// m.ByWeight().ThenBy(byName, byIdentifier) sorts by weight, then by name
+// and then by identifier.
+// The sort order of m is known if m is the result of SortBy or one of
+// the By methods. For other menus, e.g. the result of Reverse or ThenBy,
+// the less functions are the only sort keys, and entries equal by all of
+// them keep their current order.
+// Unlike SortFunc, m is left untouched, which makes it safe to use
+// on the cached results of the other sort methods.
+func (m Menu) ThenBy(less ...func(a, b *MenuEntry) bool) Menu {
+	var keys []menuEntryBy
+	if prev := smc.order(m); prev != nil {
+		keys = append(keys, prev)
+	}
+	for _, l := range less {
+		keys = append(keys, l)
+	}
+
+	menu := m.Clone()
+	menuEntryBy(func(m1, m2 *MenuEntry) bool {
+		for _, less := range keys {
+			if less(m1, m2) {
+				return true
+			}
+			if less(m2, m1) {
+				return false
+			}
+		}
+		return false
+	}).Sort(menu)
+	return menu
+}
+
 // Limit limits the returned menu to n entries.
 func (m Menu) Limit(n int) Menu {
 	if len(m) > n {
@@ -265,10 +940,202 @@ func (m Menu) Limit(n int) Menu {
 	return m
 }
 
+// Slice returns the entries from start up to, but not including, end.
+// Out of range indices are clamped to the bounds of the menu.
+func (m Menu) Slice(start, end int) Menu {
+	if start < 0 {
+		start = 0
+	}
+	if end > len(m) {
+		end = len(m)
+	}
+	if start >= end {
+		return Menu{}
+	}
+	return m[start:end]
+}
+
+// Chunk splits the menu into n menus of as equal size as possible,
+// preserving the order. The first menus get the extra entries when the
+// entries cannot be evenly divided.
+// If the menu has fewer than n entries, fewer than n menus are returned,
+// one per entry, so no returned menu is empty.
+func (m Menu) Chunk(n int) []Menu {
+	if n <= 0 || len(m) == 0 {
+		return nil
+	}
+	if n > len(m) {
+		n = len(m)
+	}
+
+	size, extra := len(m)/n, len(m)%n
+	chunks := make([]Menu, n)
+	start := 0
+	for i := range chunks {
+		end := start + size
+		if i < extra {
+			end++
+		}
+		chunks[i] = m[start:end]
+		start = end
+	}
+	return chunks
+}
+
+// Filter returns a new menu with the entries for which predicate returns true,
+// in the original order.
+func (m Menu) Filter(predicate func(*MenuEntry) bool) Menu {
+	filtered := Menu{}
+	for _, me := range m {
+		if predicate(me) {
+			filtered = append(filtered, me)
+		}
+	}
+	return filtered
+}
+
+// Partition returns two new menus, the top level entries for which predicate
+// returns true and the rest, both in the original order.
+// The children of the entries are not considered.
+func (m Menu) Partition(predicate func(*MenuEntry) bool) (Menu, Menu) {
+	matched, unmatched := Menu{}, Menu{}
+	for _, me := range m {
+		if predicate(me) {
+			matched = append(matched, me)
+		} else {
+			unmatched = append(unmatched, me)
+		}
+	}
+	return matched, unmatched
+}
+
+// FilterByParam returns a new menu with the entries with the param key set
+// to value, compared as strings, so 1 and "1" are considered equal.
+// With recursive set, the children are filtered too, and an entry with any
+// matching descendants is kept, with only those, even if it doesn't match
+// itself.
+// An empty, non-nil menu is returned if there are no matches.
+func (m Menu) FilterByParam(key string, value any, recursive bool) Menu {
+	s := cast.ToString(value)
+	matches := func(me *MenuEntry) bool {
+		v := me.Params.Get(key)
+		return v != nil && cast.ToString(v) == s
+	}
+
+	if !recursive {
+		return m.Filter(matches)
+	}
+
+	return m.filterDeep(func(me *MenuEntry) bool {
+		return matches(me) || me.HasChildren()
+	})
+}
+
+// Contains returns whether any of the top level entries is equal to me,
+// see MenuEntry.IsEqual.
+func (m Menu) Contains(me *MenuEntry) bool {
+	if me == nil {
+		return false
+	}
+	for _, e := range m {
+		if e.IsEqual(me) {
+			return true
+		}
+	}
+	return false
+}
+
+// Union returns a new menu with the top level entries in m followed by the
+// entries in other not in m, see MenuEntry.IsEqual.
+// For entries in both menus, the entry in m is kept, even if other fields
+// differ.
+func (m Menu) Union(other Menu) Menu {
+	union := m.Clone()
+	for _, me := range other {
+		if !union.Contains(me) {
+			union = append(union, me)
+		}
+	}
+	return union
+}
+
+// Intersect returns a new menu with the top level entries in m also in other,
+// see MenuEntry.IsEqual, in the order of m.
+// For entries in both menus, the entry in m is kept, even if other fields
+// differ.
+func (m Menu) Intersect(other Menu) Menu {
+	var intersection Menu
+	for _, me := range m {
+		if other.Contains(me) {
+			intersection = append(intersection, me)
+		}
+	}
+	return intersection
+}
+
+// Find returns the first top level entry with the given identifier (see KeyName),
+// or nil if not found.
+func (m Menu) Find(identifier string) *MenuEntry {
+	for _, me := range m {
+		if me.KeyName() == identifier {
+			return me
+		}
+	}
+	return nil
+}
+
 // ByWeight sorts the menu by the weight defined in the menu configuration.
 func (m Menu) ByWeight() Menu {
 	const key = "menuSort.ByWeight"
-	menus, _ := smc.get(key, menuEntryBy(defaultMenuEntrySort).Sort, m)
+	menus := smc.getSorted(key, defaultMenuEntrySort, m)
+
+	return menus
+}
+
+// ByEffectiveWeight sorts the menu like ByWeight, but using
+// MenuEntry.EffectiveWeight, which falls back to the weight of the connected page.
+func (m Menu) ByEffectiveWeight() Menu {
+	const key = "menuSort.ByEffectiveWeight"
+	by := menuEntrySortByWeight((*MenuEntry).EffectiveWeight)
+	menus := smc.getSorted(key, by, m)
+
+	return menus
+}
+
+// ByDate sorts the menu entries connected to a page by the page date,
+// newest first, followed by the entries without a page sorted by weight,
+// see ByWeight. Pages with the same date are sorted by weight.
+func (m Menu) ByDate() Menu {
+	const key = "menuSort.ByDate"
+	date := func(m1, m2 *MenuEntry) bool {
+		hasPage1, hasPage2 := !types.IsNil(m1.Page), !types.IsNil(m2.Page)
+		if hasPage1 != hasPage2 {
+			return hasPage1
+		}
+		if hasPage1 {
+			d1, d2 := m1.Page.Date(), m2.Page.Date()
+			if !d1.Equal(d2) {
+				return d1.After(d2)
+			}
+		}
+		return defaultMenuEntrySort(m1, m2)
+	}
+
+	menus := smc.getSorted(key, date, m)
+
+	return menus
+}
+
+// ByWeightReverse sorts the menu by weight in descending order.
+// This is the exact reverse of ByWeight, including the name and identifier
+// tie-breakers, but done in a single pass.
+func (m Menu) ByWeightReverse() Menu {
+	const key = "menuSort.ByWeightReverse"
+	reverse := func(m1, m2 *MenuEntry) bool {
+		return defaultMenuEntrySort(m2, m1)
+	}
+
+	menus := smc.getSorted(key, reverse, m)
 
 	return menus
 }
@@ -280,11 +1147,196 @@ func (m Menu) ByName() Menu {
 		return compare.LessStrings(m1.Name, m2.Name)
 	}
 
-	menus, _ := smc.get(key, menuEntryBy(title).Sort, m)
+	menus := smc.getSorted(key, title, m)
+
+	return menus
+}
+
+// ByNameCI sorts the menu by name, ignoring case.
+// ByName also orders names ignoring case, so "apple" comes before "Zebra",
+// but uses case to order names that differ only in case, so "B" comes before "b".
+// ByNameCI considers such names equal and keeps their order in the menu.
+// Case is folded using the Unicode collation rules, not per byte, so e.g.
+// "Straße" and "STRASSE" are also considered equal; see ByNameCollated for
+// the rules of a given language.
+func (m Menu) ByNameCI() Menu {
+	const key = "menuSort.ByNameCI"
+	name := collatedNameLess(language.Und, collate.IgnoreCase)
+
+	menus := smc.getSorted(key, name, m)
+
+	return menus
+}
+
+// ByNameCollated sorts the menu by name using the collation rules of the
+// given language, e.g. "sv" sorts "Äpple" after "Zebra".
+// An invalid language falls back to English.
+func (m Menu) ByNameCollated(lang string) Menu {
+	key := "menuSort.ByNameCollated." + lang
+	tag, err := language.Parse(lang)
+	if err != nil {
+		tag = language.English
+	}
+	name := collatedNameLess(tag)
+
+	menus := smc.getSorted(key, name, m)
+
+	return menus
+}
+
+// collatedNameLess returns a less function comparing the names using
+// the collation rules of the given language.
+// It is safe for concurrent use, e.g. in ThenBy on a shared sorted menu.
+func collatedNameLess(tag language.Tag, opts ...collate.Option) menuEntryBy {
+	// The collator is not thread safe, so pool them.
+	collators := &sync.Pool{
+		New: func() any {
+			return collate.New(tag, opts...)
+		},
+	}
+	return func(m1, m2 *MenuEntry) bool {
+		coll := collators.Get().(*collate.Collator)
+		less := coll.CompareString(m1.Name, m2.Name) < 0
+		collators.Put(coll)
+		return less
+	}
+}
+
+// ByIdentifier sorts the menu by the identifier defined in the menu configuration,
+// falling back to the name for entries without an identifier.
+func (m Menu) ByIdentifier() Menu {
+	const key = "menuSort.ByIdentifier"
+	identifier := func(m1, m2 *MenuEntry) bool {
+		return compare.LessStrings(m1.KeyName(), m2.KeyName())
+	}
+
+	menus := smc.getSorted(key, identifier, m)
+
+	return menus
+}
+
+// ByTitle sorts the menu by the resolved title, see Title.
+// Entries without a title are sorted last.
+func (m Menu) ByTitle() Menu {
+	const key = "menuSort.ByTitle"
+	title := func(m1, m2 *MenuEntry) bool {
+		t1, t2 := m1.Title(), m2.Title()
+		if t1 == "" || t2 == "" {
+			return t2 == "" && t1 != ""
+		}
+		return compare.LessStrings(t1, t2)
+	}
+
+	menus := smc.getSorted(key, title, m)
+
+	return menus
+}
+
+// ByParam sorts the menu by the given param defined in the menu entries' params.
+// Entries without the param are sorted last.
+func (m Menu) ByParam(name string) Menu {
+	key := "menuSort.ByParam." + name
+
+	paramComparator := func(m1, m2 *MenuEntry) bool {
+		v1 := m1.Params.Get(name)
+		v2 := m2.Params.Get(name)
+
+		if v1 == nil {
+			return false
+		}
+
+		if v2 == nil {
+			return true
+		}
+
+		return lessParamValues(v1, v2)
+	}
+
+	menus := smc.getSorted(key, paramComparator, m)
+
+	return menus
+}
+
+// menuEntryComparators are the keys available in SortBy.
+var menuEntryComparators = map[string]func(m1, m2 *MenuEntry) int{
+	"weight": func(m1, m2 *MenuEntry) int {
+		switch {
+		case m1.Weight == m2.Weight:
+			return 0
+		case m2.Weight == 0:
+			return -1
+		case m1.Weight == 0:
+			return 1
+		case m1.Weight < m2.Weight:
+			return -1
+		default:
+			return 1
+		}
+	},
+	"name": func(m1, m2 *MenuEntry) int {
+		return compare.Strings(m1.Name, m2.Name)
+	},
+	"title": func(m1, m2 *MenuEntry) int {
+		return compare.Strings(m1.Title(), m2.Title())
+	},
+	"identifier": func(m1, m2 *MenuEntry) int {
+		return compare.Strings(m1.KeyName(), m2.KeyName())
+	},
+}
+
+// SortBy sorts the menu by the primary key, using the secondary key for entries
+// that are equal by the primary key.
+// The keys available are "weight", "name", "title" and "identifier".
+// The secondary key may be empty.
+// An invalid key is logged as a warning and the menu is returned unchanged.
+func (m Menu) SortBy(primary, secondary string) Menu {
+	primary, secondary = strings.ToLower(primary), strings.ToLower(secondary)
+
+	c1, found := menuEntryComparators[primary]
+	if !found {
+		helpers.DistinctWarnLog.Warnf("invalid menu sort key %q", primary)
+		return m
+	}
+	c2 := func(m1, m2 *MenuEntry) int { return 0 }
+	if secondary != "" {
+		c2, found = menuEntryComparators[secondary]
+		if !found {
+			helpers.DistinctWarnLog.Warnf("invalid menu sort key %q", secondary)
+			return m
+		}
+	}
+
+	key := "menuSort.SortBy." + primary + "." + secondary
+	by := func(m1, m2 *MenuEntry) bool {
+		if c := c1(m1, m2); c != 0 {
+			return c < 0
+		}
+		return c2(m1, m2) < 0
+	}
+
+	menus := smc.getSorted(key, by, m)
 
 	return menus
 }
 
+// lessParamValues compares numeric values as numbers and anything else as strings.
+func lessParamValues(v1, v2 any) bool {
+	isNumeric := func(v any) bool {
+		switch v.(type) {
+		case uint8, uint16, uint32, uint64, int, int8, int16, int32, int64, float32, float64:
+			return true
+		default:
+			return false
+		}
+	}
+
+	if isNumeric(v1) && isNumeric(v2) {
+		return cast.ToFloat64(v1) < cast.ToFloat64(v2)
+	}
+
+	return compare.LessStrings(cast.ToString(v1), cast.ToString(v2))
+}
+
 // Reverse reverses the order of the menu entries.
 func (m Menu) Reverse() Menu {
 	const key = "menuSort.Reverse"
@@ -298,20 +1350,139 @@ func (m Menu) Reverse() Menu {
 	return menus
 }
 
+// ReindexWeights returns a deep copy of the menu tree, see CloneDeep, with the
+// top level entries given the weights step, 2*step, 3*step etc. in their
+// current order, so m is left untouched.
+// A step less than 1 is treated as 1.
+func (m Menu) ReindexWeights(step int) Menu {
+	if step < 1 {
+		step = 1
+	}
+	reindexed := m.CloneDeep()
+	for i, me := range reindexed {
+		me.Weight = (i + 1) * step
+	}
+	return reindexed
+}
+
+// Shuffle returns the menu entries in a pseudo-random order derived from seed.
+// The same seed always gives the same order for the same menu.
+// The children of the entries are left untouched.
+func (m Menu) Shuffle(seed int64) Menu {
+	key := "menuSort.Shuffle." + strconv.FormatInt(seed, 10)
+	shuffle := func(menu Menu) {
+		r := rand.New(rand.NewSource(seed))
+		r.Shuffle(len(menu), func(i, j int) {
+			menu[i], menu[j] = menu[j], menu[i]
+		})
+	}
+	menus, _ := smc.get(key, shuffle, m)
+
+	return menus
+}
+
 // Clone clones the menu entries.
+// This is a shallow copy, the entries are shared with m; see CloneDeep.
 // This is for internal use only.
 func (m Menu) Clone() Menu {
 	return append(Menu(nil), m...)
 }
 
+// Title returns the title of this menu entry if set, else the LinkTitle
+// of the connected page, if any.
+// The LinkTitle is cached when the page is connected with SetPage and when an
+// assembled menu is set up with InitTree, so Page implementations should not
+// expect LinkTitle to be called on every invocation. The cache is not used if
+// Page has been replaced since.
 func (m *MenuEntry) Title() string {
 	if m.title != "" {
 		return m.title
 	}
 
 	if m.Page != nil {
+		if m.linkTitlePage != nil && m.linkTitlePage == m.Page {
+			return m.linkTitle
+		}
 		return m.Page.LinkTitle()
 	}
 
 	return ""
 }
+
+// DisplayName returns the label to display for this menu entry, which is,
+// in order of precedence, the Name, the Title or the last path segment of the URL.
+func (m *MenuEntry) DisplayName() string {
+	if m.Name != "" {
+		return m.Name
+	}
+	if title := m.Title(); title != "" {
+		return title
+	}
+
+	u := stripQueryAndFragment(m.URL())
+	u = strings.TrimRight(u, "/")
+	return u[strings.LastIndex(u, "/")+1:]
+}
+
+// WithParam returns a copy of this menu entry with the param key set to value,
+// leaving this entry and its params untouched.
+// This is copy-on-write: the entry and the top level of its params are copied,
+// any nested values and the children are shared with the original.
+// The copy is not part of a menu tree, so ParentEntry, NextSibling and
+// PrevSibling return nil for it, while its children still have the original
+// as their parent.
+func (m *MenuEntry) WithParam(key string, value any) *MenuEntry {
+	c := *m
+	c.parent, c.siblings = nil, nil
+	c.Params = make(maps.Params, len(m.Params)+1)
+	for k, v := range m.Params {
+		c.Params[k] = v
+	}
+	c.Params[strings.ToLower(key)] = value
+	return &c
+}
+
+// DecodeParams decodes the params of this menu entry into target,
+// typically a pointer to a struct.
+func (m *MenuEntry) DecodeParams(target any) error {
+	if err := mapstructure.WeakDecode(m.Params, target); err != nil {
+		return errors.Wrapf(err, "failed to decode params of menu entry %q", m.KeyName())
+	}
+	return nil
+}
+
+// ParamString returns the param with the given key as a string.
+// The key lookup is case insensitive.
+func (m *MenuEntry) ParamString(key string) string {
+	return cast.ToString(m.Params.Get(key))
+}
+
+// ParamInt returns the param with the given key as an int.
+// The key lookup is case insensitive.
+func (m *MenuEntry) ParamInt(key string) int {
+	return cast.ToInt(m.Params.Get(key))
+}
+
+// ParamBool returns the param with the given key as a bool.
+// The key lookup is case insensitive.
+func (m *MenuEntry) ParamBool(key string) bool {
+	return cast.ToBool(m.Params.Get(key))
+}
+
+// ParamTime returns the param with the given key parsed as a date, e.g.
+// "2022-06-01", using the same parsing as for dates in front matter.
+// Dates without a time zone are in UTC.
+// The key lookup is case insensitive.
+// It returns the zero time if the param is not set, and an error if the
+// value cannot be parsed.
+func (m *MenuEntry) ParamTime(key string) (time.Time, error) {
+	v := m.Params.Get(key)
+	if v == nil {
+		return time.Time{}, nil
+	}
+	t, err := htime.ToTimeInDefaultLocationE(v, time.UTC)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to parse param %q of menu entry %q as a date", key, m.KeyName())
+	}
+	return t, nil
+}
@@ -0,0 +1,131 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package navigation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MenuEntryBuilder builds a MenuEntry, see NewMenuEntry.
+type MenuEntryBuilder struct {
+	m map[string]any
+}
+
+// NewMenuEntry creates a new builder for a menu entry with the given name.
+// The entry is created with the same semantics as an entry defined in config,
+// e.g. the params keys are lower cased.
+func NewMenuEntry(name string) *MenuEntryBuilder {
+	return &MenuEntryBuilder{m: map[string]any{"name": name}}
+}
+
+// WithIdentifier sets the identifier of the menu entry.
+func (b *MenuEntryBuilder) WithIdentifier(identifier string) *MenuEntryBuilder {
+	b.m["identifier"] = identifier
+	return b
+}
+
+// WithURL sets the URL of the menu entry.
+func (b *MenuEntryBuilder) WithURL(url string) *MenuEntryBuilder {
+	b.m["url"] = url
+	return b
+}
+
+// WithWeight sets the weight of the menu entry.
+func (b *MenuEntryBuilder) WithWeight(weight int) *MenuEntryBuilder {
+	b.m["weight"] = weight
+	return b
+}
+
+// WithParent sets the identifier of the parent menu entry.
+func (b *MenuEntryBuilder) WithParent(parent string) *MenuEntryBuilder {
+	b.m["parent"] = parent
+	return b
+}
+
+// WithParams sets the params of the menu entry.
+func (b *MenuEntryBuilder) WithParams(params map[string]any) *MenuEntryBuilder {
+	b.m["params"] = params
+	return b
+}
+
+// Build creates the menu entry.
+func (b *MenuEntryBuilder) Build() (*MenuEntry, error) {
+	me := &MenuEntry{}
+	if err := me.MarshallMap(b.m); err != nil {
+		return nil, err
+	}
+	return me, nil
+}
+
+// NewMenuFromMaps creates a menu from the given items, each using the same
+// keys as a menu entry defined in config, see MenuEntry.MarshallMap.
+// Entries with a parent set are placed below the entry with that identifier
+// (see MenuEntry.KeyName), the menu tree is sorted (see Menu.SortRecursive)
+// and set up with Menu.InitTree.
+// All problems found, including unknown parents and parent cycles, are
+// reported in the returned error.
+func NewMenuFromMaps(items []map[string]any) (Menu, error) {
+	var problems []string
+	newError := func() error {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+
+	var flat Menu
+	for i, item := range items {
+		me := &MenuEntry{}
+		if err := me.MarshallMap(item); err != nil {
+			problems = append(problems, fmt.Sprintf("menu item %d: %s", i, err))
+			continue
+		}
+		flat = append(flat, me)
+	}
+
+	if err := flat.DetectCycles(); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if len(problems) > 0 {
+		return nil, newError()
+	}
+
+	keys := make(map[string]*MenuEntry)
+	for _, me := range flat {
+		if _, found := keys[me.KeyName()]; !found {
+			keys[me.KeyName()] = me
+		}
+	}
+
+	var menu Menu
+	for _, me := range flat {
+		if me.Parent == "" {
+			menu = append(menu, me)
+			continue
+		}
+		parent, found := keys[me.Parent]
+		if !found {
+			problems = append(problems, fmt.Sprintf("menu entry %q: parent %q not found", me.KeyName(), me.Parent))
+			continue
+		}
+		parent.Children = append(parent.Children, me)
+	}
+
+	if len(problems) > 0 {
+		return nil, newError()
+	}
+
+	return menu.SortRecursive(), nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package navigation
+
+import (
+	"testing"
+
+	"github.com/gohugoio/hugo/common/maps"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestNewMenuEntry(t *testing.T) {
+	c := qt.New(t)
+
+	me, err := NewMenuEntry("Intro").
+		WithIdentifier("intro").
+		WithURL("/docs/intro/").
+		WithWeight(10).
+		WithParent("docs").
+		WithParams(map[string]any{"Icon": "book"}).
+		Build()
+
+	c.Assert(err, qt.IsNil)
+
+	var expect MenuEntry
+	c.Assert(expect.MarshallMap(map[string]any{
+		"name":       "Intro",
+		"identifier": "intro",
+		"url":        "/docs/intro/",
+		"weight":     10,
+		"parent":     "docs",
+		"params":     map[string]any{"icon": "book"},
+	}), qt.IsNil)
+
+	c.Assert(me.DeepEqual(&expect), qt.IsTrue)
+	c.Assert(me.Params, qt.DeepEquals, maps.Params{"icon": "book"})
+}
+
+func TestNewMenuFromMaps(t *testing.T) {
+	c := qt.New(t)
+
+	m, err := NewMenuFromMaps([]map[string]any{
+		{"name": "Intro", "identifier": "intro", "parent": "docs", "weight": 2},
+		{"name": "Blog", "weight": 2},
+		{"name": "Docs", "identifier": "docs", "weight": 1},
+		{"name": "Install", "parent": "docs", "weight": 1},
+		{"name": "Config", "parent": "Install"},
+	})
+	c.Assert(err, qt.IsNil)
+	c.Assert(m.Tree(), qt.Equals, `docs|weight:1|url:
+  Install|weight:1|url:
+    Config|weight:0|url:
+  intro|weight:2|url:
+Blog|weight:2|url:
+`)
+	c.Assert(m.FindDeep("Config").Depth(), qt.Equals, 2)
+	c.Assert(m.FindDeep("intro").ParentEntry(), qt.Equals, m[0])
+
+	m, err = NewMenuFromMaps(nil)
+	c.Assert(err, qt.IsNil)
+	c.Assert(m, qt.HasLen, 0)
+
+	_, err = NewMenuFromMaps([]map[string]any{
+		{"name": "A", "params": "foo"},
+		{"name": "B", "parent": "C"},
+		{"name": "C", "parent": "B"},
+	})
+	c.Assert(err, qt.ErrorMatches, `menu item 0: failed to marshal menu entry "A": cannot convert string to Params\nmenu entry parent cycle detected: B -> C -> B`)
+
+	_, err = NewMenuFromMaps([]map[string]any{
+		{"name": "A", "parent": "x"},
+		{"name": "B", "parent": "y"},
+	})
+	c.Assert(err, qt.ErrorMatches, `menu entry "A": parent "x" not found\nmenu entry "B": parent "y" not found`)
+}
@@ -14,12 +14,16 @@
 package navigation
 
 import (
+	"sort"
 	"sync"
 )
 
 type menuCacheEntry struct {
 	in  []Menu
 	out Menu
+
+	// The less function out is sorted by, if known, see getSorted.
+	less menuEntryBy
 }
 
 func (entry menuCacheEntry) matches(menuList []Menu) bool {
@@ -83,10 +87,48 @@ func (c *menuCache) get(key string, apply func(m Menu), menuLists ...Menu) (Menu
 	}, menuLists...)
 }
 
-func (c *menuCache) getP(key string, apply func(m *Menu), menuLists ...Menu) (Menu, bool) {
-	c.Lock()
-	defer c.Unlock()
+// getSorted is like get, but sorts the menu using less and stores less
+// with the sorted menu, see order.
+func (c *menuCache) getSorted(key string, less menuEntryBy, m Menu) Menu {
+	menus, _ := c.getEntry(key, less, func(menu *Menu) {
+		less.Sort(*menu)
+	}, []Menu{m})
+	return menus
+}
+
+// order returns the less function m was sorted with, if m is a sorted menu
+// returned from getSorted and still in that order, else nil.
+func (c *menuCache) order(m Menu) menuEntryBy {
+	if len(m) == 0 {
+		return nil
+	}
 
+	var less menuEntryBy
+	c.RLock()
+	for _, cached := range c.m {
+		for _, entry := range cached {
+			// The same slice, not just the same entries.
+			if entry.less != nil && len(entry.out) == len(m) && &entry.out[0] == &m[0] {
+				less = entry.less
+				break
+			}
+		}
+		if less != nil {
+			break
+		}
+	}
+	c.RUnlock()
+
+	// The cached menu may have been sorted in place after it was cached.
+	if less == nil || !sort.IsSorted(&menuSorter{menu: m, by: less}) {
+		return nil
+	}
+	return less
+}
+
+// lookup returns the cached menu for key and menuLists, if any.
+// The caller must hold at least a read lock.
+func (c *menuCache) lookup(key string, menuLists []Menu) (Menu, bool) {
 	if cached, ok := c.m[key]; ok {
 		for _, entry := range cached {
 			if entry.matches(menuLists) {
@@ -94,15 +136,45 @@ func (c *menuCache) getP(key string, apply func(m *Menu), menuLists ...Menu) (Me
 			}
 		}
 	}
+	return nil, false
+}
+
+// getP returns the cached menu for key and menuLists, or, if not found,
+// applies apply to a copy of the first menu in menuLists and caches the result.
+// The menus passed are never modified, which makes it safe to use
+// concurrently on shared menus.
+// Cache hits only take the read lock; on a miss, the lookup is repeated
+// holding the write lock before apply is called, so apply runs once per
+// key and menuLists.
+func (c *menuCache) getP(key string, apply func(m *Menu), menuLists ...Menu) (Menu, bool) {
+	return c.getEntry(key, nil, apply, menuLists)
+}
+
+// getEntry is getP storing less, if set, with the cached menu.
+func (c *menuCache) getEntry(key string, less menuEntryBy, apply func(m *Menu), menuLists []Menu) (Menu, bool) {
+	c.RLock()
+	m, found := c.lookup(key, menuLists)
+	c.RUnlock()
+	if found {
+		return m, true
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	// Check again, another goroutine may have added it.
+	if m, found := c.lookup(key, menuLists); found {
+		return m, true
+	}
 
-	m := menuLists[0]
+	m = menuLists[0]
 	menuCopy := append(Menu(nil), m...)
 
 	if apply != nil {
 		apply(&menuCopy)
 	}
 
-	entry := menuCacheEntry{in: menuLists, out: menuCopy}
+	entry := menuCacheEntry{in: menuLists, out: menuCopy, less: less}
 	if v, ok := c.m[key]; ok {
 		c.m[key] = append(v, entry)
 	} else {
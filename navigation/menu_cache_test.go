@@ -79,3 +79,37 @@ func TestMenuCache(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestMenuCacheConcurrentSorts(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+
+	menu := make(Menu, 20)
+	for i := range menu {
+		menu[i] = &MenuEntry{Name: string(rune('a' + i)), Weight: len(menu) - i}
+	}
+	original := menu.Clone()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				byWeight := menu.ByWeight()
+				c.Assert(byWeight[0].Weight, qt.Equals, 1)
+				byName := menu.ByName()
+				c.Assert(byName[0].Name, qt.Equals, "a")
+				reversed := byWeight.Reverse()
+				c.Assert(reversed[0].Weight, qt.Equals, len(menu))
+				c.Assert(byWeight[0].Weight, qt.Equals, 1)
+				c.Assert(menu.ByWeightReverse()[0].Weight, qt.Equals, len(menu))
+				// The collator is shared by the cached sort and ThenBy.
+				c.Assert(menu.ByNameCollated("sv").ThenBy(func(a, b *MenuEntry) bool { return false })[0].Name, qt.Equals, "a")
+			}
+		}()
+	}
+	wg.Wait()
+
+	c.Assert(menuEqual(menu, original), qt.IsTrue)
+}
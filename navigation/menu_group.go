@@ -0,0 +1,127 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package navigation
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/gohugoio/hugo/common/types"
+
+	"github.com/pkg/errors"
+)
+
+// MenuGroup is a group of menu entries sharing the same key.
+type MenuGroup struct {
+	// The key, typically a param value.
+	Key any
+
+	// The menu entries in this group.
+	Menu Menu
+}
+
+// GroupByParam groups the menu entries by the given param.
+// The groups are sorted by key, the entries within a group keep their
+// order from m.
+// It is an error if any of the entries is missing the param.
+func (m Menu) GroupByParam(name string) ([]MenuGroup, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+
+	var groups []MenuGroup
+	indices := make(map[any]int)
+
+	for _, me := range m {
+		v := me.Params.Get(name)
+		if v == nil {
+			return nil, errors.Errorf("menu entry %q is missing param %q", me.KeyName(), name)
+		}
+		if !reflect.TypeOf(v).Comparable() {
+			return nil, errors.Errorf("param %q of type %T in menu entry %q cannot be used as a group key", name, v, me.KeyName())
+		}
+
+		i, found := indices[v]
+		if !found {
+			i = len(groups)
+			indices[v] = i
+			groups = append(groups, MenuGroup{Key: v})
+		}
+		groups[i].Menu = append(groups[i].Menu, me)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return lessParamValues(groups[i].Key, groups[j].Key)
+	})
+
+	return groups, nil
+}
+
+// ByTranslationKey groups the top level menu entries by their TranslationKey,
+// falling back to the identifier (see MenuEntry.KeyName) for
+// entries without one.
+// The entries within a group keep their order from m.
+func (m Menu) ByTranslationKey() map[string]Menu {
+	groups := make(map[string]Menu)
+	for _, me := range m {
+		key := me.TranslationKey
+		if key == "" {
+			key = me.KeyName()
+		}
+		groups[key] = append(groups[key], me)
+	}
+	return groups
+}
+
+// BySection groups the top level menu entries by the section of their connected
+// pages. Entries not connected to a page are not included.
+// The entries within a group keep their order from m.
+func (m Menu) BySection() map[string]Menu {
+	groups := make(map[string]Menu)
+	for _, me := range m {
+		if types.IsNil(me.Page) {
+			continue
+		}
+		section := me.Page.Section()
+		groups[section] = append(groups[section], me)
+	}
+	return groups
+}
+
+// GroupByHost groups the top level menu entries by the host of their URLs,
+// see MenuEntry.Host. Entries with relative URLs are not included.
+// The entries within a group keep their order from m.
+func (m Menu) GroupByHost() map[string]Menu {
+	groups := make(map[string]Menu)
+	for _, me := range m {
+		host := me.Host()
+		if host == "" {
+			continue
+		}
+		groups[host] = append(groups[host], me)
+	}
+	return groups
+}
+
+// ByDepth groups all the entries in the menu tree by their nesting level,
+// starting at 1 for the top level entries, see Menu.MaxDepth.
+// The entries within a level are in depth first order.
+func (m Menu) ByDepth() map[int]Menu {
+	levels := make(map[int]Menu)
+	m.walk(func(me *MenuEntry, depth int) bool {
+		levels[depth+1] = append(levels[depth+1], me)
+		return true
+	})
+	return levels
+}
@@ -0,0 +1,111 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package navigation
+
+import (
+	"testing"
+
+	"github.com/gohugoio/hugo/common/maps"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestMenuGroupByParam(t *testing.T) {
+	c := qt.New(t)
+
+	m := Menu{
+		{Identifier: "b1", Params: maps.Params{"section": "b"}},
+		{Identifier: "a1", Params: maps.Params{"section": "a"}},
+		{Identifier: "b2", Params: maps.Params{"section": "b"}},
+	}
+
+	groups, err := m.GroupByParam("section")
+	c.Assert(err, qt.IsNil)
+	c.Assert(groups, qt.HasLen, 2)
+	c.Assert(groups[0].Key, qt.Equals, "a")
+	c.Assert(menuKeyNames(groups[0].Menu), qt.DeepEquals, []string{"a1"})
+	c.Assert(groups[1].Key, qt.Equals, "b")
+	c.Assert(menuKeyNames(groups[1].Menu), qt.DeepEquals, []string{"b1", "b2"})
+
+	m = append(m, &MenuEntry{Identifier: "none"})
+	_, err = m.GroupByParam("section")
+	c.Assert(err, qt.ErrorMatches, `menu entry "none" is missing param "section"`)
+}
+
+func TestMenuByTranslationKey(t *testing.T) {
+	c := qt.New(t)
+
+	var me MenuEntry
+	c.Assert(me.MarshallMap(map[string]any{"identifier": "about", "translationKey": "about-us"}), qt.IsNil)
+	c.Assert(me.TranslationKey, qt.Equals, "about-us")
+
+	m := Menu{&me, {Identifier: "a1", TranslationKey: "a"}, {Identifier: "b"}, {Identifier: "a2", TranslationKey: "a"}}
+	groups := m.ByTranslationKey()
+
+	c.Assert(groups, qt.HasLen, 3)
+	c.Assert(menuKeyNames(groups["about-us"]), qt.DeepEquals, []string{"about"})
+	c.Assert(menuKeyNames(groups["a"]), qt.DeepEquals, []string{"a1", "a2"})
+	c.Assert(menuKeyNames(groups["b"]), qt.DeepEquals, []string{"b"})
+}
+
+func TestMenuBySection(t *testing.T) {
+	c := qt.New(t)
+
+	m := Menu{
+		{Identifier: "d1", Page: newTestPage("/docs/p1")},
+		{Identifier: "b1", Page: newTestPage("/blog/p1")},
+		{Identifier: "ext", ConfiguredURL: "https://example.org"},
+		{Identifier: "d2", Page: newTestPage("/docs/p2")},
+	}
+
+	groups := m.BySection()
+	c.Assert(groups, qt.HasLen, 2)
+	c.Assert(menuKeyNames(groups["docs"]), qt.DeepEquals, []string{"d1", "d2"})
+	c.Assert(menuKeyNames(groups["blog"]), qt.DeepEquals, []string{"b1"})
+}
+
+func TestMenuGroupByHost(t *testing.T) {
+	c := qt.New(t)
+
+	m := Menu{
+		{Identifier: "gh1", ConfiguredURL: "https://github.com/gohugoio/hugo"},
+		{Identifier: "local", ConfiguredURL: "/about/"},
+		{Identifier: "ex", ConfiguredURL: "http://Example.org:8080/foo"},
+		{Identifier: "bad", ConfiguredURL: "http://[::1"},
+		{Identifier: "gh2", ConfiguredURL: "//github.com/bep"},
+	}
+
+	c.Assert(m[0].Host(), qt.Equals, "github.com")
+	c.Assert(m[1].Host(), qt.Equals, "")
+	c.Assert(m[2].Host(), qt.Equals, "example.org")
+	c.Assert(m[3].Host(), qt.Equals, "")
+
+	groups := m.GroupByHost()
+	c.Assert(groups, qt.HasLen, 2)
+	c.Assert(menuKeyNames(groups["github.com"]), qt.DeepEquals, []string{"gh1", "gh2"})
+	c.Assert(menuKeyNames(groups["example.org"]), qt.DeepEquals, []string{"ex"})
+}
+
+func TestMenuByDepth(t *testing.T) {
+	c := qt.New(t)
+
+	m := createTreeTestMenu()
+
+	levels := m.ByDepth()
+	c.Assert(levels, qt.HasLen, m.MaxDepth())
+	c.Assert(menuKeyNames(levels[1]), qt.DeepEquals, []string{"a", "b"})
+	c.Assert(menuKeyNames(levels[2]), qt.DeepEquals, []string{"a1", "a2"})
+	c.Assert(menuKeyNames(levels[3]), qt.DeepEquals, []string{"a11"})
+	c.Assert(Menu(nil).ByDepth(), qt.HasLen, 0)
+}
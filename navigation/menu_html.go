@@ -0,0 +1,179 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package navigation
+
+import (
+	"html/template"
+	"sort"
+	"strings"
+)
+
+// ToHTML renders the menu tree as nested HTML lists, with a link to
+// the URL of each entry using the DisplayName as text, the Title
+// as the title attribute and the attributes from HTMLAttributes.
+// Pre and Post, see RenderPre and RenderPost, are rendered as is before
+// and after the link, while PreSR and PostSR are rendered at the start and
+// the end of the link text, in a span with the "visually-hidden" class.
+// URLs with a scheme other than http, https, mailto and tel, e.g. "javascript:",
+// are replaced with "#ZgotmplZ", as in Go's html/template.
+// This is a basic renderer meant for prototypes and defaults, use
+// a menu template for anything else.
+func (m Menu) ToHTML() template.HTML {
+	var b strings.Builder
+	m.writeHTML(&b, make(map[*MenuEntry]bool))
+	return template.HTML(b.String())
+}
+
+// HTMLAttributes returns the HTML attributes for the link of this menu
+// entry, built from Class, Rel and Target, in that order, followed by
+// the DataAttributes.
+// "noopener" is added to rel for external links.
+// The values are HTML escaped, e.g.
+//
+//	class="nav" rel="noopener" target="_blank"
+func (m *MenuEntry) HTMLAttributes() template.HTMLAttr {
+	rel := strings.Fields(m.Rel)
+	if m.IsExternal() && !hasString(rel, "noopener") {
+		rel = append(rel, "noopener")
+	}
+
+	var attrs []string
+	addAttr := func(name, value string) {
+		if value != "" {
+			attrs = append(attrs, name+`="`+template.HTMLEscapeString(value)+`"`)
+		}
+	}
+	addAttr("class", m.Class)
+	addAttr("rel", strings.Join(rel, " "))
+	addAttr("target", m.Target)
+	if data := m.DataAttributes(); data != "" {
+		attrs = append(attrs, string(data))
+	}
+
+	return template.HTMLAttr(strings.Join(attrs, " "))
+}
+
+// DataAttributes returns the Data of this menu entry as HTML data attributes
+// sorted by key, e.g.
+//
+//	data-toggle="dropdown"
+//
+// The keys are lower cased and the values are HTML escaped.
+// Keys that are not valid attribute names are skipped.
+func (m *MenuEntry) DataAttributes() template.HTMLAttr {
+	keys := make([]string, 0, len(m.Data))
+	for k := range m.Data {
+		if isDataAttributeName(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	attrs := make([]string, len(keys))
+	for i, k := range keys {
+		attrs[i] = "data-" + strings.ToLower(k) + `="` + template.HTMLEscapeString(m.Data[k]) + `"`
+	}
+
+	return template.HTMLAttr(strings.Join(attrs, " "))
+}
+
+// isDataAttributeName reports whether s can be used as the name of a data
+// attribute after the "data-" prefix.
+func isDataAttributeName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// filterURL returns s, or "#ZgotmplZ" if s is a URL with an unsafe scheme.
+func filterURL(s string) string {
+	scheme, _, found := strings.Cut(s, ":")
+	if !found || strings.ContainsAny(scheme, "/?#") {
+		// A relative URL.
+		return s
+	}
+	switch strings.ToLower(scheme) {
+	case "http", "https", "mailto", "tel":
+		return s
+	}
+	return "#ZgotmplZ"
+}
+
+// writeSRText writes s, if set, in a span only visible to screen readers.
+func writeSRText(b *strings.Builder, s string) {
+	if s == "" {
+		return
+	}
+	b.WriteString(`<span class="visually-hidden">`)
+	b.WriteString(template.HTMLEscapeString(s))
+	b.WriteString("</span>")
+}
+
+func hasString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// writeHTML writes m and its descendants, ancestors holds the entries
+// on the path from the top level down to m, which are skipped.
+func (m Menu) writeHTML(b *strings.Builder, ancestors map[*MenuEntry]bool) {
+	var ul bool
+	for _, me := range m {
+		if ancestors[me] {
+			continue
+		}
+		if !ul {
+			b.WriteString("<ul>")
+			ul = true
+		}
+
+		b.WriteString("<li>")
+		b.WriteString(string(me.RenderPre()))
+		b.WriteString(`<a href="`)
+		b.WriteString(template.HTMLEscapeString(filterURL(me.URL())))
+		b.WriteString(`"`)
+		if title := me.Title(); title != "" {
+			b.WriteString(` title="`)
+			b.WriteString(template.HTMLEscapeString(title))
+			b.WriteString(`"`)
+		}
+		if attrs := me.HTMLAttributes(); attrs != "" {
+			b.WriteString(" ")
+			b.WriteString(string(attrs))
+		}
+		b.WriteString(">")
+		writeSRText(b, me.PreSR)
+		b.WriteString(template.HTMLEscapeString(me.DisplayName()))
+		writeSRText(b, me.PostSR)
+		b.WriteString("</a>")
+		b.WriteString(string(me.RenderPost()))
+		ancestors[me] = true
+		me.Children.writeHTML(b, ancestors)
+		delete(ancestors, me)
+		b.WriteString("</li>")
+	}
+	if ul {
+		b.WriteString("</ul>")
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package navigation
+
+import (
+	"html/template"
+	"testing"
+
+	"github.com/gohugoio/hugo/common/maps"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestMenuToHTML(t *testing.T) {
+	c := qt.New(t)
+
+	m := Menu{
+		{
+			Name:          "Docs & Guides",
+			ConfiguredURL: "/docs/?a=1&b=2",
+			Pre:           template.HTML("<i>"),
+			Post:          template.HTML("</i>"),
+			Children: Menu{
+				{Name: "Intro", ConfiguredURL: "/docs/intro/", title: `The "intro"`},
+			},
+		},
+		{Name: "Blog", ConfiguredURL: "/blog/"},
+	}
+
+	c.Assert(m.ToHTML(), qt.Equals, template.HTML(`<ul><li><i><a href="/docs/?a=1&amp;b=2">Docs &amp; Guides</a></i><ul><li><a href="/docs/intro/" title="The &#34;intro&#34;">Intro</a></li></ul></li><li><a href="/blog/">Blog</a></li></ul>`))
+	c.Assert(Menu{}.ToHTML(), qt.Equals, template.HTML(""))
+}
+
+func TestMenuToHTMLShared(t *testing.T) {
+	c := qt.New(t)
+
+	// The same entry in two different lists is rendered in both.
+	s := &MenuEntry{Name: "S", ConfiguredURL: "/s/"}
+	m := Menu{
+		{Name: "A", ConfiguredURL: "/a/", Children: Menu{s}},
+		{Name: "B", ConfiguredURL: "/b/", Children: Menu{s}},
+	}
+	c.Assert(m.ToHTML(), qt.Equals, template.HTML(`<ul><li><a href="/a/">A</a><ul><li><a href="/s/">S</a></li></ul></li><li><a href="/b/">B</a><ul><li><a href="/s/">S</a></li></ul></li></ul>`))
+
+	// Cycles are left out, without an empty list.
+	a := &MenuEntry{Name: "A", ConfiguredURL: "/a/"}
+	a.Children = Menu{a}
+	c.Assert(Menu{a}.ToHTML(), qt.Equals, template.HTML(`<ul><li><a href="/a/">A</a></li></ul>`))
+}
+
+func TestMenuToHTMLURLs(t *testing.T) {
+	c := qt.New(t)
+
+	for _, test := range []struct {
+		url    string
+		expect string
+	}{
+		{"/about/", "/about/"},
+		{"about/", "about/"},
+		{"#top", "#top"},
+		{"", ""},
+		{"/a:b/", "/a:b/"},
+		{"?q=a:b", "?q=a:b"},
+		{"http://example.org", "http://example.org"},
+		{"HTTPS://example.org", "HTTPS://example.org"},
+		{"mailto:noreply@example.com", "mailto:noreply@example.com"},
+		{"tel:+4712345678", "tel:+4712345678"},
+		{"javascript:alert(1)", "#ZgotmplZ"},
+		{"JavaScript:alert(1)", "#ZgotmplZ"},
+		{" javascript:alert(1)", "#ZgotmplZ"},
+		{"data:text/html,foo", "#ZgotmplZ"},
+		{"vbscript:foo", "#ZgotmplZ"},
+	} {
+		m := Menu{{Name: "A", ConfiguredURL: test.url}}
+		c.Assert(string(m.ToHTML()), qt.Contains, `<a href="`+test.expect+`"`, qt.Commentf(test.url))
+	}
+}
+
+func TestMenuEntryHTMLAttributes(t *testing.T) {
+	c := qt.New(t)
+
+	c.Assert((&MenuEntry{ConfiguredURL: "/about/"}).HTMLAttributes(), qt.Equals, template.HTMLAttr(""))
+	c.Assert((&MenuEntry{ConfiguredURL: "/about/", Class: "nav active"}).HTMLAttributes(), qt.Equals, template.HTMLAttr(`class="nav active"`))
+	c.Assert((&MenuEntry{ConfiguredURL: "https://example.org", Target: "_blank", Class: "ext"}).HTMLAttributes(), qt.Equals, template.HTMLAttr(`class="ext" rel="noopener" target="_blank"`))
+	c.Assert((&MenuEntry{ConfiguredURL: "https://example.org", Rel: "noopener noreferrer"}).HTMLAttributes(), qt.Equals, template.HTMLAttr(`rel="noopener noreferrer"`))
+	c.Assert((&MenuEntry{ConfiguredURL: "https://example.org", Rel: "me"}).HTMLAttributes(), qt.Equals, template.HTMLAttr(`rel="me noopener"`))
+	c.Assert((&MenuEntry{ConfiguredURL: "/", Class: `a" onclick="x`}).HTMLAttributes(), qt.Equals, template.HTMLAttr(`class="a&#34; onclick=&#34;x"`))
+
+	m := Menu{{Name: "Ext", ConfiguredURL: "https://example.org", Target: "_blank"}}
+	c.Assert(m.ToHTML(), qt.Equals, template.HTML(`<ul><li><a href="https://example.org" rel="noopener" target="_blank">Ext</a></li></ul>`))
+}
+
+func TestMenuEntryRenderPrePost(t *testing.T) {
+	c := qt.New(t)
+
+	me := &MenuEntry{Name: "Inbox", ConfiguredURL: "/inbox/", Pre: "<i>", Post: "</i>"}
+	c.Assert(me.RenderPre(), qt.Equals, template.HTML("<i>"))
+	c.Assert(me.RenderPost(), qt.Equals, template.HTML("</i>"))
+
+	me.PostFunc = func(me *MenuEntry) template.HTML {
+		return template.HTML(`<span class="badge">` + me.ParamString("count") + `</span>`)
+	}
+	me.Params = maps.Params{"count": "3"}
+	c.Assert(me.RenderPre(), qt.Equals, template.HTML("<i>"))
+	c.Assert(me.RenderPost(), qt.Equals, template.HTML(`<span class="badge">3</span>`))
+	c.Assert(Menu{me}.ToHTML(), qt.Equals, template.HTML(`<ul><li><i><a href="/inbox/">Inbox</a><span class="badge">3</span></li></ul>`))
+}
+
+func TestMenuEntryDataAttributes(t *testing.T) {
+	c := qt.New(t)
+
+	var me MenuEntry
+	c.Assert(me.MarshallMap(map[string]any{
+		"url":  "/docs/",
+		"data": map[string]any{"Toggle": "dropdown", "target": `#a"b`, "bad key": "x"},
+	}), qt.IsNil)
+
+	c.Assert(me.Data, qt.DeepEquals, map[string]string{"toggle": "dropdown", "target": `#a"b`, "bad key": "x"})
+	c.Assert(me.DataAttributes(), qt.Equals, template.HTMLAttr(`data-target="#a&#34;b" data-toggle="dropdown"`))
+
+	me.Class = "nav"
+	c.Assert(me.HTMLAttributes(), qt.Equals, template.HTMLAttr(`class="nav" data-target="#a&#34;b" data-toggle="dropdown"`))
+	c.Assert((&MenuEntry{}).DataAttributes(), qt.Equals, template.HTMLAttr(""))
+
+	c.Assert(me.MarshallMap(map[string]any{"data": "foo"}), qt.ErrorMatches, `failed to marshal menu entry .*: cannot convert string to data attributes`)
+}
+
+func TestMenuToHTMLScreenReaderText(t *testing.T) {
+	c := qt.New(t)
+
+	var me MenuEntry
+	c.Assert(me.MarshallMap(map[string]any{
+		"name":   "GitHub",
+		"url":    "https://github.com/gohugoio/hugo",
+		"pre":    "<i>",
+		"post":   "</i>",
+		"preSR":  "Source:",
+		"postSR": "(opens in a new <tab>)",
+	}), qt.IsNil)
+	c.Assert(me.PreSR, qt.Equals, "Source:")
+
+	c.Assert(Menu{&me}.ToHTML(), qt.Equals, template.HTML(`<ul><li><i><a href="https://github.com/gohugoio/hugo" rel="noopener"><span class="visually-hidden">Source:</span>GitHub<span class="visually-hidden">(opens in a new &lt;tab&gt;)</span></a></i></li></ul>`))
+}
@@ -0,0 +1,151 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package navigation
+
+import (
+	"encoding/json"
+	"html/template"
+
+	"github.com/gohugoio/hugo/common/maps"
+	"github.com/gohugoio/hugo/common/types"
+)
+
+// menuEntryPageJSON is the JSON representation of the Page
+// connected to a menu entry.
+type menuEntryPageJSON struct {
+	RelPermalink string `json:"relPermalink"`
+	Path         string `json:"path"`
+}
+
+// MarshalJSON returns the JSON encoding of m.
+func (m Menu) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]*MenuEntry(m))
+}
+
+// MarshalJSON returns the JSON encoding of m, including the resolved URL
+// and Title.
+// A connected Page is represented by its RelPermalink and Path only.
+func (m *MenuEntry) MarshalJSON() ([]byte, error) {
+	var page *menuEntryPageJSON
+	if !types.IsNil(m.Page) {
+		page = &menuEntryPageJSON{
+			RelPermalink: m.Page.RelPermalink(),
+			Path:         m.Page.Path(),
+		}
+	}
+
+	return json.Marshal(&struct {
+		Identifier     string             `json:"identifier,omitempty"`
+		Name           string             `json:"name"`
+		Title          string             `json:"title,omitempty"`
+		URL            string             `json:"url"`
+		ConfiguredURL  string             `json:"configuredURL,omitempty"`
+		PageRef        string             `json:"pageRef,omitempty"`
+		Page           *menuEntryPageJSON `json:"page,omitempty"`
+		Menu           string             `json:"menu,omitempty"`
+		Parent         string             `json:"parent,omitempty"`
+		Weight         int                `json:"weight"`
+		Pre            template.HTML      `json:"pre,omitempty"`
+		Post           template.HTML      `json:"post,omitempty"`
+		PreSR          string             `json:"preSR,omitempty"`
+		PostSR         string             `json:"postSR,omitempty"`
+		Target         string             `json:"target,omitempty"`
+		Rel            string             `json:"rel,omitempty"`
+		Class          string             `json:"class,omitempty"`
+		Icon           string             `json:"icon,omitempty"`
+		Aliases        []string           `json:"aliases,omitempty"`
+		Data           map[string]string  `json:"data,omitempty"`
+		Disabled       bool               `json:"disabled,omitempty"`
+		Hidden         bool               `json:"hidden,omitempty"`
+		TranslationKey string             `json:"translationKey,omitempty"`
+		Params         maps.Params        `json:"params,omitempty"`
+		Children       Menu               `json:"children,omitempty"`
+	}{
+		Identifier:     m.Identifier,
+		Name:           m.Name,
+		Title:          m.Title(),
+		URL:            m.URL(),
+		ConfiguredURL:  m.ConfiguredURL,
+		PageRef:        m.PageRef,
+		Page:           page,
+		Menu:           m.Menu,
+		Parent:         m.Parent,
+		Weight:         m.Weight,
+		Pre:            m.Pre,
+		Post:           m.Post,
+		PreSR:          m.PreSR,
+		PostSR:         m.PostSR,
+		Target:         m.Target,
+		Rel:            m.Rel,
+		Class:          m.Class,
+		Icon:           m.Icon,
+		Aliases:        m.Aliases,
+		Data:           m.Data,
+		Disabled:       m.Disabled,
+		Hidden:         m.Hidden,
+		TranslationKey: m.TranslationKey,
+		Params:         m.Params,
+		Children:       m.Children,
+	})
+}
+
+// MarshalYAML returns the YAML representation of m using the same keys as
+// read by MarshallMap, so a menu entry defined in config can be written back.
+// Empty values are omitted.
+func (m *MenuEntry) MarshalYAML() (any, error) {
+	v := make(map[string]any)
+
+	setString := func(key, value string) {
+		if value != "" {
+			v[key] = value
+		}
+	}
+
+	setString("url", m.ConfiguredURL)
+	setString("pageRef", m.PageRef)
+	setString("name", m.Name)
+	setString("title", m.title)
+	setString("pre", string(m.Pre))
+	setString("post", string(m.Post))
+	setString("preSR", m.PreSR)
+	setString("postSR", m.PostSR)
+	setString("target", m.Target)
+	setString("rel", m.Rel)
+	setString("class", m.Class)
+	setString("icon", m.Icon)
+	setString("identifier", m.Identifier)
+	setString("translationKey", m.TranslationKey)
+	setString("parent", m.Parent)
+
+	if m.Weight != 0 {
+		v["weight"] = m.Weight
+	}
+	if m.Disabled {
+		v["disabled"] = true
+	}
+	if m.Hidden {
+		v["hidden"] = true
+	}
+	if len(m.Aliases) > 0 {
+		v["aliases"] = m.Aliases
+	}
+	if len(m.Data) > 0 {
+		v["data"] = m.Data
+	}
+	if len(m.Params) > 0 {
+		v["params"] = m.Params
+	}
+
+	return v, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package navigation
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gohugoio/hugo/common/maps"
+
+	qt "github.com/frankban/quicktest"
+	"gopkg.in/yaml.v2"
+)
+
+func TestMenuMarshalJSON(t *testing.T) {
+	c := qt.New(t)
+
+	p := newTestPage("/docs")
+	p.linkTitle = "Docs"
+
+	m := Menu{
+		{
+			Identifier: "docs",
+			Name:       "Documentation",
+			Page:       p,
+			Weight:     10,
+			Params:     maps.Params{"foo": "bar"},
+			Children: Menu{
+				{Identifier: "ext", Name: "External", ConfiguredURL: "https://example.org", Parent: "docs", Target: "_blank"},
+			},
+		},
+	}
+
+	b, err := json.Marshal(m)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(b), qt.Equals, `[{"identifier":"docs","name":"Documentation","title":"Docs","url":"/docs/","page":{"relPermalink":"/docs/","path":"/docs"},"weight":10,"params":{"foo":"bar"},"children":[{"identifier":"ext","name":"External","url":"https://example.org","configuredURL":"https://example.org","parent":"docs","weight":0,"target":"_blank"}]}]`)
+}
+
+func TestMenuEntryMarshalYAML(t *testing.T) {
+	c := qt.New(t)
+
+	var me MenuEntry
+	c.Assert(me.MarshallMap(map[string]any{
+		"identifier": "about",
+		"name":       "About",
+		"title":      "About us",
+		"pageRef":    "/about",
+		"weight":     20,
+		"pre":        "<i></i>",
+		"params":     map[string]any{"foo": "bar"},
+	}), qt.IsNil)
+
+	b, err := yaml.Marshal(&me)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(b), qt.Equals, `identifier: about
+name: About
+pageRef: /about
+params:
+  foo: bar
+pre: <i></i>
+title: About us
+weight: 20
+`)
+
+	var m map[string]any
+	c.Assert(yaml.Unmarshal(b, &m), qt.IsNil)
+	var me2 MenuEntry
+	c.Assert(me2.MarshallMap(m), qt.IsNil)
+	c.Assert(me2.DeepEqual(&me), qt.IsTrue)
+	c.Assert(me2.Title(), qt.Equals, "About us")
+	c.Assert(me2.Pre, qt.Equals, me.Pre)
+}
@@ -0,0 +1,1092 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package navigation
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gohugoio/hugo/common/maps"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func menuKeyNames(m Menu) []string {
+	var names []string
+	for _, e := range m {
+		names = append(names, e.KeyName())
+	}
+	return names
+}
+
+func TestMenuByIdentifier(t *testing.T) {
+	c := qt.New(t)
+
+	m := Menu{
+		{Identifier: "c", Name: "A", Weight: 1},
+		{Name: "b", Weight: 1},
+		{Identifier: "a", Name: "C", Weight: 1},
+	}
+
+	c.Assert(menuKeyNames(m.ByIdentifier()), qt.DeepEquals, []string{"a", "b", "c"})
+	// The original is left untouched.
+	c.Assert(menuKeyNames(m), qt.DeepEquals, []string{"c", "b", "a"})
+}
+
+func TestMenuByTitle(t *testing.T) {
+	c := qt.New(t)
+
+	m := Menu{
+		{Identifier: "none"},
+		{Identifier: "b", title: "B"},
+		{Identifier: "a", title: "a"},
+	}
+
+	c.Assert(menuKeyNames(m.ByTitle()), qt.DeepEquals, []string{"a", "b", "none"})
+}
+
+func TestMenuByWeightReverse(t *testing.T) {
+	c := qt.New(t)
+
+	m := Menu{
+		{Identifier: "w0"},
+		{Identifier: "w2b", Name: "b", Weight: 2},
+		{Identifier: "w1", Weight: 1},
+		{Identifier: "w2a", Name: "a", Weight: 2},
+	}
+
+	c.Assert(menuKeyNames(m.ByWeight()), qt.DeepEquals, []string{"w1", "w2a", "w2b", "w0"})
+	c.Assert(menuKeyNames(m.ByWeightReverse()), qt.DeepEquals, []string{"w0", "w2b", "w2a", "w1"})
+	c.Assert(menuKeyNames(m.ByWeightReverse()), qt.DeepEquals, menuKeyNames(m.ByWeight().Reverse()))
+}
+
+func TestMenuEntryIsExternal(t *testing.T) {
+	c := qt.New(t)
+
+	for _, test := range []struct {
+		url    string
+		expect bool
+	}{
+		{"", false},
+		{"/about/", false},
+		{"about", false},
+		{"#team", false},
+		{"https://example.org/", true},
+		{"//example.org/foo", true},
+		{"mailto:noreply@example.com", true},
+		{"tel:+4712345678", true},
+	} {
+		me := &MenuEntry{ConfiguredURL: test.url}
+		c.Assert(me.IsExternal(), qt.Equals, test.expect, qt.Commentf(test.url))
+	}
+}
+
+func TestMenuEntryIsAnchor(t *testing.T) {
+	c := qt.New(t)
+
+	me := &MenuEntry{ConfiguredURL: "#intro"}
+	c.Assert(me.IsAnchor(), qt.IsTrue)
+	c.Assert(me.URL(), qt.Equals, "#intro")
+	c.Assert(me.Fragment(), qt.Equals, "intro")
+	c.Assert(me.IsExternal(), qt.IsFalse)
+	c.Assert(me.IsActive(newTestPage("/docs/intro")), qt.IsFalse)
+
+	me.Page = newTestPage("/docs/p1")
+	c.Assert(me.URL(), qt.Equals, "#intro")
+
+	c.Assert((&MenuEntry{ConfiguredURL: "#"}).IsAnchor(), qt.IsTrue)
+	c.Assert((&MenuEntry{ConfiguredURL: "/about/#intro"}).IsAnchor(), qt.IsFalse)
+	c.Assert((&MenuEntry{ConfiguredURL: "/about/#intro", Page: newTestPage("/about")}).URL(), qt.Equals, "/about/")
+	c.Assert((&MenuEntry{}).IsAnchor(), qt.IsFalse)
+}
+
+func TestMenuEntryFragment(t *testing.T) {
+	c := qt.New(t)
+
+	for _, test := range []struct {
+		url    string
+		expect string
+	}{
+		{"", ""},
+		{"/about/", ""},
+		{"/about#team", "team"},
+		{"/about/?q=a#team", "team"},
+		{"#team", "team"},
+		{"/about#", ""},
+	} {
+		me := &MenuEntry{ConfiguredURL: test.url}
+		c.Assert(me.Fragment(), qt.Equals, test.expect, qt.Commentf(test.url))
+	}
+}
+
+func TestMenuFilter(t *testing.T) {
+	c := qt.New(t)
+
+	m := Menu{
+		{Identifier: "a", Weight: 1},
+		{Identifier: "b", Weight: 2},
+		{Identifier: "c", Weight: 3},
+	}
+
+	odd := m.Filter(func(me *MenuEntry) bool { return me.Weight%2 == 1 })
+	c.Assert(menuKeyNames(odd), qt.DeepEquals, []string{"a", "c"})
+	c.Assert(len(m), qt.Equals, 3)
+
+	none := m.Filter(func(me *MenuEntry) bool { return false })
+	c.Assert(none, qt.Not(qt.IsNil))
+	c.Assert(none, qt.HasLen, 0)
+}
+
+func TestMenuPartition(t *testing.T) {
+	c := qt.New(t)
+
+	m := Menu{
+		{Identifier: "a", Weight: 1},
+		{Identifier: "b", Weight: 2, Children: Menu{{Identifier: "b1", Weight: 1}}},
+		{Identifier: "c", Weight: 3},
+	}
+
+	odd, even := m.Partition(func(me *MenuEntry) bool { return me.Weight%2 == 1 })
+	c.Assert(menuKeyNames(odd), qt.DeepEquals, []string{"a", "c"})
+	c.Assert(menuKeyNames(even), qt.DeepEquals, []string{"b"})
+	c.Assert(even[0].Children, qt.HasLen, 1)
+
+	all, none := m.Partition(func(me *MenuEntry) bool { return true })
+	c.Assert(all, qt.HasLen, 3)
+	c.Assert(none, qt.Not(qt.IsNil))
+	c.Assert(none, qt.HasLen, 0)
+}
+
+func TestMenuFilterByParam(t *testing.T) {
+	c := qt.New(t)
+
+	m := Menu{
+		{Identifier: "a", Params: maps.Params{"tag": "x"}, Children: Menu{
+			{Identifier: "a1", Params: maps.Params{"tag": "y"}},
+			{Identifier: "a2", Params: maps.Params{"tag": "x"}},
+		}},
+		{Identifier: "b", Children: Menu{
+			{Identifier: "b1", Params: maps.Params{"tag": "x"}},
+		}},
+		{Identifier: "c", Params: maps.Params{"tag": 1}},
+		{Identifier: "d"},
+	}
+
+	c.Assert(menuKeyNames(m.FilterByParam("tag", "x", false)), qt.DeepEquals, []string{"a"})
+	c.Assert(menuKeyNames(m.FilterByParam("tag", "1", false)), qt.DeepEquals, []string{"c"})
+	c.Assert(menuKeyNames(m.FilterByParam("Tag", 1, false)), qt.DeepEquals, []string{"c"})
+
+	deep := m.FilterByParam("tag", "x", true)
+	c.Assert(menuKeyNames(deep), qt.DeepEquals, []string{"a", "b"})
+	c.Assert(menuKeyNames(deep[0].Children), qt.DeepEquals, []string{"a2"})
+	c.Assert(menuKeyNames(deep[1].Children), qt.DeepEquals, []string{"b1"})
+	c.Assert(m[0].Children, qt.HasLen, 2)
+
+	none := m.FilterByParam("tag", "z", true)
+	c.Assert(none, qt.Not(qt.IsNil))
+	c.Assert(none, qt.HasLen, 0)
+	c.Assert(m.FilterByParam("tag", "", false), qt.HasLen, 0)
+}
+
+func TestMenuByParam(t *testing.T) {
+	c := qt.New(t)
+
+	m := Menu{
+		{Identifier: "none"},
+		{Identifier: "ten", Params: maps.Params{"order": 10}},
+		{Identifier: "two", Params: maps.Params{"order": 2.0}},
+		{Identifier: "b", Params: maps.Params{"order": "b"}},
+	}
+
+	c.Assert(menuKeyNames(m.ByParam("order")), qt.DeepEquals, []string{"two", "ten", "b", "none"})
+	c.Assert(menuKeyNames(m.ByParam("Order")), qt.DeepEquals, []string{"two", "ten", "b", "none"})
+}
+
+func TestMenuEntryTypedParams(t *testing.T) {
+	c := qt.New(t)
+
+	me := &MenuEntry{Params: maps.Params{"s": "foo", "i": "32", "b": true}}
+
+	c.Assert(me.ParamString("S"), qt.Equals, "foo")
+	c.Assert(me.ParamInt("i"), qt.Equals, 32)
+	c.Assert(me.ParamBool("b"), qt.IsTrue)
+	c.Assert(me.ParamString("missing"), qt.Equals, "")
+	c.Assert(me.ParamInt("missing"), qt.Equals, 0)
+	c.Assert((&MenuEntry{}).ParamBool("b"), qt.IsFalse)
+}
+
+func TestMenuEntryParamTime(t *testing.T) {
+	c := qt.New(t)
+
+	me := &MenuEntry{Identifier: "new", Params: maps.Params{
+		"newuntil": "2022-06-01",
+		"datetime": "2022-06-01T10:30:00+02:00",
+		"time":     time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC),
+		"bad":      "soon",
+	}}
+
+	d, err := me.ParamTime("newUntil")
+	c.Assert(err, qt.IsNil)
+	c.Assert(d, qt.Equals, time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC))
+
+	d, err = me.ParamTime("datetime")
+	c.Assert(err, qt.IsNil)
+	c.Assert(d.Equal(time.Date(2022, 6, 1, 8, 30, 0, 0, time.UTC)), qt.IsTrue)
+
+	d, err = me.ParamTime("time")
+	c.Assert(err, qt.IsNil)
+	c.Assert(d.Equal(time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)), qt.IsTrue)
+
+	d, err = me.ParamTime("missing")
+	c.Assert(err, qt.IsNil)
+	c.Assert(d.IsZero(), qt.IsTrue)
+
+	d, err = me.ParamTime("bad")
+	c.Assert(err, qt.ErrorMatches, `failed to parse param "bad" of menu entry "new" as a date: .*`)
+	c.Assert(d.IsZero(), qt.IsTrue)
+}
+
+func TestMenuEntryIsActive(t *testing.T) {
+	c := qt.New(t)
+
+	p1, p2 := newTestPage("/docs/p1"), newTestPage("/docs/p2")
+
+	c.Assert((&MenuEntry{Page: p1}).IsActive(p1), qt.IsTrue)
+	c.Assert((&MenuEntry{Page: p1}).IsActive(p2), qt.IsFalse)
+	c.Assert((&MenuEntry{ConfiguredURL: "/docs/p1/"}).IsActive(p1), qt.IsTrue)
+	c.Assert((&MenuEntry{ConfiguredURL: "/docs/p1/"}).IsActive(p2), qt.IsFalse)
+	c.Assert((&MenuEntry{}).IsActive(p1), qt.IsFalse)
+	c.Assert((&MenuEntry{Page: p1}).IsActive(nil), qt.IsFalse)
+}
+
+func TestMenuEntryMarshallMap(t *testing.T) {
+	c := qt.New(t)
+
+	var me MenuEntry
+	c.Assert(me.MarshallMap(map[string]any{
+		"identifier": "ext",
+		"url":        "https://example.org",
+		"TARGET":     "_blank",
+		"rel":        "noopener",
+		"class":      "external",
+		"icon":       "globe",
+	}), qt.IsNil)
+
+	c.Assert(me.Identifier, qt.Equals, "ext")
+	c.Assert(me.ConfiguredURL, qt.Equals, "https://example.org")
+	c.Assert(me.Target, qt.Equals, "_blank")
+	c.Assert(me.Rel, qt.Equals, "noopener")
+	c.Assert(me.Class, qt.Equals, "external")
+	c.Assert(me.Icon, qt.Equals, "globe")
+	c.Assert(me.HasIcon(), qt.IsTrue)
+}
+
+func TestMenuSlice(t *testing.T) {
+	c := qt.New(t)
+
+	m := Menu{{Identifier: "a"}, {Identifier: "b"}, {Identifier: "c"}}
+
+	c.Assert(menuKeyNames(m.Slice(1, 2)), qt.DeepEquals, []string{"b"})
+	c.Assert(menuKeyNames(m.Slice(-1, 2)), qt.DeepEquals, []string{"a", "b"})
+	c.Assert(menuKeyNames(m.Slice(1, 10)), qt.DeepEquals, []string{"b", "c"})
+	c.Assert(m.Slice(2, 1), qt.HasLen, 0)
+	c.Assert(m.Slice(5, 10), qt.HasLen, 0)
+}
+
+func TestMenuAppendPrepend(t *testing.T) {
+	c := qt.New(t)
+
+	m := make(Menu, 0, 10)
+	m = append(m, &MenuEntry{Identifier: "b", Weight: 2}, &MenuEntry{Identifier: "c", Weight: 1})
+
+	appended := m.Append(&MenuEntry{Identifier: "a", Weight: 3})
+	c.Assert(menuKeyNames(appended), qt.DeepEquals, []string{"b", "c", "a"})
+	c.Assert(menuKeyNames(m.Prepend(&MenuEntry{Identifier: "x"}, &MenuEntry{Identifier: "y"})), qt.DeepEquals, []string{"x", "y", "b", "c"})
+
+	// Appending to m again must not overwrite the first result.
+	m.Append(&MenuEntry{Identifier: "z"})
+	c.Assert(menuKeyNames(appended), qt.DeepEquals, []string{"b", "c", "a"})
+	c.Assert(menuKeyNames(m), qt.DeepEquals, []string{"b", "c"})
+	c.Assert(menuKeyNames(Menu(nil).Append()), qt.HasLen, 0)
+}
+
+func TestMenuChunk(t *testing.T) {
+	c := qt.New(t)
+
+	m := Menu{{Identifier: "a"}, {Identifier: "b"}, {Identifier: "c"}, {Identifier: "d"}, {Identifier: "e"}}
+
+	chunks := m.Chunk(3)
+	c.Assert(chunks, qt.HasLen, 3)
+	c.Assert(menuKeyNames(chunks[0]), qt.DeepEquals, []string{"a", "b"})
+	c.Assert(menuKeyNames(chunks[1]), qt.DeepEquals, []string{"c", "d"})
+	c.Assert(menuKeyNames(chunks[2]), qt.DeepEquals, []string{"e"})
+
+	c.Assert(m.Chunk(10), qt.HasLen, 5)
+	c.Assert(m.Chunk(1), qt.HasLen, 1)
+	c.Assert(m.Chunk(0), qt.IsNil)
+}
+
+func TestMenusMerge(t *testing.T) {
+	c := qt.New(t)
+
+	m1 := Menus{
+		"main": Menu{{Identifier: "a", Name: "m1", Weight: 2}, {Identifier: "b", Weight: 3}},
+		"foot": Menu{{Identifier: "f"}},
+	}
+	m2 := Menus{
+		"main": Menu{{Identifier: "a", Name: "m2", Weight: 2}, {Identifier: "c", Weight: 1}},
+		"side": Menu{{Identifier: "s"}},
+	}
+
+	merged := m1.Merge(m2)
+
+	c.Assert(merged, qt.HasLen, 3)
+	c.Assert(menuKeyNames(merged["main"]), qt.DeepEquals, []string{"c", "a", "b"})
+	c.Assert(merged["main"].Find("a").Name, qt.Equals, "m1")
+	c.Assert(menuKeyNames(merged["foot"]), qt.DeepEquals, []string{"f"})
+	c.Assert(menuKeyNames(merged["side"]), qt.DeepEquals, []string{"s"})
+	c.Assert(menuKeyNames(m1["main"]), qt.DeepEquals, []string{"a", "b"})
+}
+
+func TestMenuEntryDeepEqual(t *testing.T) {
+	c := qt.New(t)
+
+	create := func() *MenuEntry {
+		return &MenuEntry{Identifier: "a", Name: "A", Weight: 1, ConfiguredURL: "/a/", Params: maps.Params{"foo": []string{"bar"}}}
+	}
+
+	c.Assert(create().DeepEqual(create()), qt.IsTrue)
+
+	m := create()
+	m.Weight = 2
+	c.Assert(m.IsEqual(create()), qt.IsTrue)
+	c.Assert(m.DeepEqual(create()), qt.IsFalse)
+
+	m = create()
+	m.Params["foo"] = []string{"baz"}
+	c.Assert(m.DeepEqual(create()), qt.IsFalse)
+
+	m = create()
+	m.Params = nil
+	c.Assert(m.DeepEqual(create()), qt.IsFalse)
+}
+
+func TestMenuEntryAbsURL(t *testing.T) {
+	c := qt.New(t)
+
+	for _, test := range []struct {
+		base   string
+		url    string
+		expect string
+	}{
+		{"https://example.org/", "/about/", "https://example.org/about/"},
+		{"https://example.org", "/about/", "https://example.org/about/"},
+		{"https://example.org", "about/", "https://example.org/about/"},
+		{"https://example.org/docs/", "/docs/about/", "https://example.org/docs/about/"},
+		{"https://example.org/docs/", "about/", "https://example.org/docs/about/"},
+		{"https://example.org/docs", "about/", "https://example.org/docs/about/"},
+		{"https://example.org/docs/v1", "about/#foo", "https://example.org/docs/v1/about/#foo"},
+		{"https://example.org/docs", "/about/", "https://example.org/about/"},
+		{"https://example.org/", "https://example.com/foo", "https://example.com/foo"},
+		{"https://example.org/", "mailto:noreply@example.com", "mailto:noreply@example.com"},
+		{"https://example.org/", "", ""},
+	} {
+		me := &MenuEntry{ConfiguredURL: test.url}
+		c.Assert(me.AbsURL(test.base), qt.Equals, test.expect, qt.Commentf("%s %s", test.base, test.url))
+	}
+
+	me := &MenuEntry{Page: newTestPage("/docs/p1")}
+	c.Assert(me.AbsURL("https://example.org/"), qt.Equals, "https://example.org/docs/p1/")
+}
+
+func TestMenuEntryPermalink(t *testing.T) {
+	c := qt.New(t)
+
+	c.Assert((&MenuEntry{Page: newTestPage("/docs/p1"), ConfiguredURL: "https://example.com/"}).Permalink(), qt.Equals, "https://example.org/docs/p1/")
+	c.Assert((&MenuEntry{ConfiguredURL: "https://example.com/blog/"}).Permalink(), qt.Equals, "https://example.com/blog/")
+	c.Assert((&MenuEntry{ConfiguredURL: "/blog/"}).Permalink(), qt.Equals, "")
+	c.Assert((&MenuEntry{ConfiguredURL: "//example.com/blog/"}).Permalink(), qt.Equals, "")
+	c.Assert((&MenuEntry{ConfiguredURL: "http://[::1"}).Permalink(), qt.Equals, "")
+	c.Assert((&MenuEntry{}).Permalink(), qt.Equals, "")
+}
+
+func TestMenuSortBy(t *testing.T) {
+	c := qt.New(t)
+
+	m := Menu{
+		{Identifier: "c", Name: "a", Weight: 1},
+		{Identifier: "b", Name: "b", Weight: 1},
+		{Identifier: "a", Name: "a", Weight: 2},
+		{Identifier: "d", Name: "c"},
+	}
+
+	c.Assert(menuKeyNames(m.SortBy("weight", "identifier")), qt.DeepEquals, []string{"b", "c", "a", "d"})
+	c.Assert(menuKeyNames(m.SortBy("name", "Weight")), qt.DeepEquals, []string{"c", "a", "b", "d"})
+	c.Assert(menuKeyNames(m.SortBy("identifier", "")), qt.DeepEquals, []string{"a", "b", "c", "d"})
+	c.Assert(menuKeyNames(m.SortBy("foo", "name")), qt.DeepEquals, []string{"c", "b", "a", "d"})
+	c.Assert(menuKeyNames(m.SortBy("name", "foo")), qt.DeepEquals, []string{"c", "b", "a", "d"})
+}
+
+func TestMenuThenBy(t *testing.T) {
+	c := qt.New(t)
+
+	byWeight := func(a, b *MenuEntry) bool { return a.Weight < b.Weight }
+	byName := func(a, b *MenuEntry) bool { return a.Name < b.Name }
+
+	m := Menu{
+		{Identifier: "b2", Name: "b", Weight: 2},
+		{Identifier: "a1", Name: "a", Weight: 1},
+		{Identifier: "b1", Name: "b", Weight: 1},
+		{Identifier: "a2", Name: "a", Weight: 2},
+		{Identifier: "c1", Name: "c", Weight: 1},
+	}
+
+	// The previous sort is the primary key.
+	c.Assert(menuKeyNames(m.ByWeight().ThenBy(byName)), qt.DeepEquals, []string{"a1", "b1", "c1", "a2", "b2"})
+	c.Assert(menuKeyNames(m.ByName().ThenBy(byWeight)), qt.DeepEquals, []string{"a1", "a2", "b1", "b2", "c1"})
+	c.Assert(menuKeyNames(m.SortBy("name", "").ThenBy(byWeight)), qt.DeepEquals, []string{"a1", "a2", "b1", "b2", "c1"})
+
+	c.Assert(menuKeyNames(m.ByNameCI().ThenBy(byWeight)), qt.DeepEquals, []string{"a1", "a2", "b1", "b2", "c1"})
+	c.Assert(menuKeyNames(m.ByNameCollated("sv").ThenBy(byWeight)), qt.DeepEquals, []string{"a1", "a2", "b1", "b2", "c1"})
+
+	// More keys.
+	byIdentifierReverse := func(a, b *MenuEntry) bool { return a.Identifier > b.Identifier }
+	c.Assert(menuKeyNames(m.ThenBy(byName, byIdentifierReverse)), qt.DeepEquals, []string{"a2", "a1", "b2", "b1", "c1"})
+	c.Assert(menuKeyNames(m.ByIdentifier().Reverse().ThenBy(byName, byWeight)), qt.DeepEquals, []string{"a1", "a2", "b1", "b2", "c1"})
+
+	// Not a sorted menu, the less functions are the only keys and equal
+	// entries keep their original order.
+	c.Assert(menuKeyNames(m.ThenBy(byWeight)), qt.DeepEquals, []string{"a1", "b1", "c1", "b2", "a2"})
+	c.Assert(menuKeyNames(m.Reverse().ThenBy(byWeight)), qt.DeepEquals, []string{"c1", "b1", "a1", "a2", "b2"})
+	c.Assert(menuKeyNames(m.ByName().ThenBy(byWeight).ThenBy(byIdentifierReverse)), qt.DeepEquals, []string{"c1", "b2", "b1", "a2", "a1"})
+	c.Assert(len(Menu(nil).ThenBy(byWeight)), qt.Equals, 0)
+
+	// A cached sort sorted in place is no longer sorted by weight,
+	// so the weight is not used as the primary key.
+	sorted := Menu{
+		{Identifier: "c", Name: "b", Weight: 1},
+		{Identifier: "a", Name: "a", Weight: 2},
+		{Identifier: "b", Name: "b", Weight: 3},
+	}.ByWeight()
+	sorted.SortFunc(byName)
+	c.Assert(menuKeyNames(sorted.ThenBy(byIdentifierReverse)), qt.DeepEquals, []string{"c", "b", "a"})
+
+	// Nothing is stored per call.
+	before := cachedEntries()
+	for i := 0; i < 100; i++ {
+		m.ByWeight().ThenBy(byName)
+	}
+	c.Assert(cachedEntries(), qt.Equals, before)
+
+	// The receiver, here a cached sort, is not modified.
+	c.Assert(menuKeyNames(m.ByWeight()), qt.DeepEquals, []string{"a1", "b1", "c1", "a2", "b2"})
+	c.Assert(menuKeyNames(m)[0], qt.Equals, "b2")
+}
+
+func TestMenuEntryURLForLang(t *testing.T) {
+	c := qt.New(t)
+
+	for _, test := range []struct {
+		prefix string
+		url    string
+		expect string
+	}{
+		{"fr", "/", "/fr/"},
+		{"fr", "/about/", "/fr/about/"},
+		{"/fr/", "/about/", "/fr/about/"},
+		{"fr", "/fr/about/", "/fr/about/"},
+		{"fr", "/fr", "/fr"},
+		{"fr", "/fruits/", "/fr/fruits/"},
+		{"fr", "about/", "about/"},
+		{"fr", "https://example.org/", "https://example.org/"},
+		{"fr", "//example.org/", "//example.org/"},
+		{"", "/about/", "/about/"},
+	} {
+		me := &MenuEntry{ConfiguredURL: test.url}
+		c.Assert(me.URLForLang(test.prefix), qt.Equals, test.expect, qt.Commentf("%s %s", test.prefix, test.url))
+	}
+
+	me := &MenuEntry{Page: newTestPage("/docs/p1")}
+	c.Assert(me.URLForLang("fr"), qt.Equals, "/docs/p1/")
+}
+
+func TestMenuByNameCI(t *testing.T) {
+	c := qt.New(t)
+
+	m := Menu{
+		{Identifier: "b", Name: "b"},
+		{Identifier: "Zebra", Name: "Zebra"},
+		{Identifier: "B", Name: "B"},
+		{Identifier: "apple", Name: "apple"},
+	}
+
+	c.Assert(menuKeyNames(m.ByName()), qt.DeepEquals, []string{"apple", "B", "b", "Zebra"})
+	c.Assert(menuKeyNames(m.ByNameCI()), qt.DeepEquals, []string{"apple", "b", "B", "Zebra"})
+
+	// Not just ASCII.
+	m = Menu{
+		{Identifier: "4", Name: "STRASSE"},
+		{Identifier: "1", Name: "Éclair"},
+		{Identifier: "5", Name: "Straße"},
+		{Identifier: "2", Name: "ÉCLAIRE"},
+		{Identifier: "3", Name: "Ñandú"},
+		{Identifier: "6", Name: "straße"},
+		{Identifier: "7", Name: "ΣΊΣΥΦΟΣ"},
+		{Identifier: "0", Name: "apple"},
+		{Identifier: "8", Name: "σίσυφος"},
+	}
+	c.Assert(menuKeyNames(m.ByNameCI()), qt.DeepEquals, []string{"0", "1", "2", "3", "4", "5", "6", "7", "8"})
+}
+
+func TestMenuEntryPathSegments(t *testing.T) {
+	c := qt.New(t)
+
+	c.Assert((&MenuEntry{PageRef: "/docs/intro/"}).PathSegments(), qt.DeepEquals, []string{"docs", "intro"})
+	c.Assert((&MenuEntry{PageRef: "docs//intro"}).PathSegments(), qt.DeepEquals, []string{"docs", "intro"})
+	c.Assert((&MenuEntry{Page: newTestPage("/blog/p1")}).PathSegments(), qt.DeepEquals, []string{"blog", "p1"})
+	c.Assert((&MenuEntry{}).PathSegments(), qt.DeepEquals, []string{})
+}
+
+func TestMenuEntryDisplayName(t *testing.T) {
+	c := qt.New(t)
+
+	c.Assert((&MenuEntry{Name: "name", title: "title"}).DisplayName(), qt.Equals, "name")
+	c.Assert((&MenuEntry{title: "title", ConfiguredURL: "/about/"}).DisplayName(), qt.Equals, "title")
+	c.Assert((&MenuEntry{Page: newTestPage("/docs/p1")}).DisplayName(), qt.Equals, "/docs/p1")
+	c.Assert((&MenuEntry{ConfiguredURL: "/about/team/"}).DisplayName(), qt.Equals, "team")
+	c.Assert((&MenuEntry{ConfiguredURL: "/about/team?q=a#b"}).DisplayName(), qt.Equals, "team")
+	c.Assert((&MenuEntry{ConfiguredURL: "/"}).DisplayName(), qt.Equals, "")
+}
+
+func TestMenuEntryQueryParam(t *testing.T) {
+	c := qt.New(t)
+
+	me := &MenuEntry{ConfiguredURL: "/search?type=docs&type=blog&q=a+b#results"}
+	c.Assert(me.QueryParam("type"), qt.Equals, "docs")
+	c.Assert(me.QueryParam("q"), qt.Equals, "a b")
+	c.Assert(me.QueryParam("missing"), qt.Equals, "")
+	c.Assert((&MenuEntry{ConfiguredURL: "/search/"}).QueryParam("type"), qt.Equals, "")
+}
+
+func TestMenusGet(t *testing.T) {
+	c := qt.New(t)
+
+	main := Menu{{Identifier: "a"}}
+	menus := Menus{"main": main, "Footer": Menu{{Identifier: "f"}}}
+
+	c.Assert(menuEqual(menus.Get("main"), main), qt.IsTrue)
+	c.Assert(menuEqual(menus.Get("Main"), main), qt.IsTrue)
+	c.Assert(menus.Get("footer").Find("f"), qt.Not(qt.IsNil))
+	c.Assert(menus.Get("side"), qt.IsNil)
+
+	// Names differing only in case.
+	menus = Menus{"Main": Menu{{Identifier: "a"}}, "MAIN": Menu{{Identifier: "b"}}, "main": nil}
+	c.Assert(menus.Get("Main").Find("a"), qt.Not(qt.IsNil))
+	c.Assert(menus.Get("MAIN").Find("b"), qt.Not(qt.IsNil))
+	c.Assert(menus.Get("main"), qt.IsNil)
+	delete(menus, "main")
+	for i := 0; i < 20; i++ {
+		c.Assert(menus.Get("mAiN").Find("b"), qt.Not(qt.IsNil))
+		c.Assert(menus.Has("main"), qt.IsTrue)
+	}
+}
+
+func TestMenusHasNames(t *testing.T) {
+	c := qt.New(t)
+
+	menus := Menus{"main": Menu{{Identifier: "a"}}, "Footer": Menu{{Identifier: "f"}}, "empty": nil}
+
+	c.Assert(menus.Has("main"), qt.IsTrue)
+	c.Assert(menus.Has("MAIN"), qt.IsTrue)
+	c.Assert(menus.Has("footer"), qt.IsTrue)
+	c.Assert(menus.Has("empty"), qt.IsTrue)
+	c.Assert(menus.Has("side"), qt.IsFalse)
+	c.Assert(Menus(nil).Has("main"), qt.IsFalse)
+
+	c.Assert(menus.Names(), qt.DeepEquals, []string{"Footer", "empty", "main"})
+	c.Assert(Menus{}.Names(), qt.HasLen, 0)
+}
+
+func TestMenuEntryRelPermalinkWithFragment(t *testing.T) {
+	c := qt.New(t)
+
+	p := newTestPage("/about")
+
+	c.Assert((&MenuEntry{Page: p, Params: maps.Params{"fragment": "team"}}).RelPermalinkWithFragment(), qt.Equals, "/about/#team")
+	c.Assert((&MenuEntry{Page: p, Params: maps.Params{"fragment": "#team"}}).RelPermalinkWithFragment(), qt.Equals, "/about/#team")
+	c.Assert((&MenuEntry{Page: p}).RelPermalinkWithFragment(), qt.Equals, "/about/")
+	c.Assert((&MenuEntry{ConfiguredURL: "/about/#us", Params: maps.Params{"fragment": "team"}}).RelPermalinkWithFragment(), qt.Equals, "/about/#us")
+}
+
+func TestMenusSorted(t *testing.T) {
+	c := qt.New(t)
+
+	menus := Menus{"main": Menu{{Identifier: "a"}}, "footer": nil, "side": Menu{}}
+
+	c.Assert(menus.Keys(), qt.DeepEquals, []string{"footer", "main", "side"})
+	sorted := menus.Sorted()
+	c.Assert(sorted, qt.HasLen, 3)
+	c.Assert(sorted[1].Name, qt.Equals, "main")
+	c.Assert(menuKeyNames(sorted[1].Menu), qt.DeepEquals, []string{"a"})
+	c.Assert(Menus(nil).Keys(), qt.HasLen, 0)
+}
+
+func TestMenuByEffectiveWeight(t *testing.T) {
+	c := qt.New(t)
+
+	p1, p2 := newTestPage("/p1"), newTestPage("/p2")
+	p1.weight, p2.weight = 30, 10
+
+	m := Menu{
+		{Identifier: "p1", Page: p1},
+		{Identifier: "w20", Weight: 20},
+		{Identifier: "p2", Page: p2},
+		{Identifier: "w0"},
+		{Identifier: "p2w40", Page: p2, Weight: 40},
+	}
+
+	c.Assert(m[0].EffectiveWeight(), qt.Equals, 30)
+	c.Assert(m[4].EffectiveWeight(), qt.Equals, 40)
+	c.Assert(menuKeyNames(m.ByEffectiveWeight()), qt.DeepEquals, []string{"p2", "w20", "p1", "p2w40", "w0"})
+}
+
+func TestMenuEntryInSection(t *testing.T) {
+	c := qt.New(t)
+
+	p1, p2, p3 := newTestPage("/docs/p1"), newTestPage("/docs/p2"), newTestPage("/blog/p3")
+
+	c.Assert((&MenuEntry{Page: p1}).InSection(p2), qt.IsTrue)
+	c.Assert((&MenuEntry{Page: p1}).InSection(p3), qt.IsFalse)
+	c.Assert((&MenuEntry{ConfiguredURL: "/docs/"}).InSection(p1), qt.IsFalse)
+	c.Assert((&MenuEntry{Page: p1}).InSection(nil), qt.IsFalse)
+}
+
+func TestMenuEntryIsPageIsSection(t *testing.T) {
+	c := qt.New(t)
+
+	page, section := &MenuEntry{Page: newTestPage("/docs/p1")}, &MenuEntry{Page: newTestPage("/docs")}
+
+	c.Assert(page.IsPage(), qt.IsTrue)
+	c.Assert(page.IsSection(), qt.IsFalse)
+	c.Assert(section.IsPage(), qt.IsFalse)
+	c.Assert(section.IsSection(), qt.IsTrue)
+
+	for _, me := range []*MenuEntry{{ConfiguredURL: "/docs/"}, {Page: (*testPage)(nil)}} {
+		c.Assert(me.IsPage(), qt.IsFalse)
+		c.Assert(me.IsSection(), qt.IsFalse)
+	}
+}
+
+func TestMenuEntryDecodeParams(t *testing.T) {
+	c := qt.New(t)
+
+	type params struct {
+		Icon    string
+		Columns int
+		Tags    []string
+	}
+
+	me := &MenuEntry{Identifier: "a", Params: maps.Params{"icon": "book", "columns": "3", "tags": []any{"a", "b"}}}
+	var p params
+	c.Assert(me.DecodeParams(&p), qt.IsNil)
+	c.Assert(p, qt.DeepEquals, params{Icon: "book", Columns: 3, Tags: []string{"a", "b"}})
+
+	me.Params["columns"] = "many"
+	c.Assert(me.DecodeParams(&p), qt.ErrorMatches, `failed to decode params of menu entry "a":(.|\n)*Columns(.|\n)*`)
+}
+
+func TestMenuEntryIsSameResourceNormalized(t *testing.T) {
+	c := qt.New(t)
+
+	same := func(u1, u2 string) bool {
+		return (&MenuEntry{ConfiguredURL: u1}).IsSameResourceNormalized(&MenuEntry{ConfiguredURL: u2})
+	}
+
+	c.Assert(same("/about", "/about/"), qt.IsTrue)
+	c.Assert(same("/about/", "/about"), qt.IsTrue)
+	c.Assert(same("/about/", "/about/"), qt.IsTrue)
+	c.Assert(same("/About", "/about/"), qt.IsTrue)
+	c.Assert(same("https://example.org/Blog/", "https://example.org/blog"), qt.IsTrue)
+	c.Assert(same("/", "/"), qt.IsTrue)
+	c.Assert(same("/about", "/about-us/"), qt.IsFalse)
+	c.Assert(same("/about?q=A", "/about/?q=a"), qt.IsFalse)
+	c.Assert(same("", ""), qt.IsFalse)
+
+	// The literal comparison is unchanged.
+	c.Assert((&MenuEntry{ConfiguredURL: "/about"}).IsSameResource(&MenuEntry{ConfiguredURL: "/about/"}), qt.IsFalse)
+}
+
+func TestMenuReverseDoesNotMutate(t *testing.T) {
+	c := qt.New(t)
+
+	m := Menu{
+		&MenuEntry{Identifier: "c", Weight: 3},
+		&MenuEntry{Identifier: "a", Weight: 1},
+		&MenuEntry{Identifier: "b", Weight: 2},
+	}
+
+	byWeight := m.ByWeight()
+	reversed := byWeight.Reverse()
+
+	c.Assert(menuKeyNames(reversed), qt.DeepEquals, []string{"c", "b", "a"})
+	c.Assert(menuKeyNames(byWeight), qt.DeepEquals, []string{"a", "b", "c"})
+	c.Assert(menuKeyNames(m.ByWeight()), qt.DeepEquals, []string{"a", "b", "c"})
+	c.Assert(menuKeyNames(m), qt.DeepEquals, []string{"c", "a", "b"})
+}
+
+func TestMenuByDate(t *testing.T) {
+	c := qt.New(t)
+
+	page := func(path string, year int) *testPage {
+		p := newTestPage(path)
+		p.date = time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+		return p
+	}
+
+	m := Menu{
+		{Identifier: "ext2", Weight: 2},
+		{Identifier: "p2020", Page: page("/blog/a", 2020)},
+		{Identifier: "ext1", Weight: 1},
+		{Identifier: "p2022b", Weight: 2, Page: page("/blog/b", 2022)},
+		{Identifier: "p2022a", Weight: 1, Page: page("/blog/c", 2022)},
+	}
+
+	c.Assert(menuKeyNames(m.ByDate()), qt.DeepEquals, []string{"p2022a", "p2022b", "p2020", "ext1", "ext2"})
+}
+
+func TestMenuReindexWeights(t *testing.T) {
+	c := qt.New(t)
+
+	m := Menu{
+		{Identifier: "a", Weight: 10},
+		{Identifier: "b", Weight: 55, Children: Menu{{Identifier: "b1", Weight: 7}}},
+		{Identifier: "c", Weight: 20},
+	}
+
+	weights := func(m Menu) []int {
+		var w []int
+		for _, me := range m {
+			w = append(w, me.Weight)
+		}
+		return w
+	}
+
+	reindexed := m.ByWeight().ReindexWeights(10)
+	c.Assert(menuKeyNames(reindexed), qt.DeepEquals, []string{"a", "c", "b"})
+	c.Assert(weights(reindexed), qt.DeepEquals, []int{10, 20, 30})
+	c.Assert(reindexed[2].Children[0].Weight, qt.Equals, 7)
+	c.Assert(reindexed[2].Children[0].ParentEntry(), qt.Equals, reindexed[2])
+	c.Assert(reindexed[0].NextSibling(), qt.Equals, reindexed[1])
+	c.Assert(weights(m), qt.DeepEquals, []int{10, 55, 20})
+	c.Assert(weights(m.ReindexWeights(0)), qt.DeepEquals, []int{1, 2, 3})
+}
+
+func TestMenuShuffle(t *testing.T) {
+	c := qt.New(t)
+
+	var m Menu
+	for i := 0; i < 20; i++ {
+		m = append(m, &MenuEntry{Identifier: fmt.Sprintf("e%d", i)})
+	}
+	m[0].Children = Menu{{Identifier: "c1"}, {Identifier: "c2"}}
+	original := menuKeyNames(m)
+
+	s1 := m.Shuffle(32)
+	c.Assert(menuKeyNames(s1), qt.Not(qt.DeepEquals), original)
+	c.Assert(menuKeyNames(m.Shuffle(32)), qt.DeepEquals, menuKeyNames(s1))
+	c.Assert(menuKeyNames(m.Clone().Shuffle(32)), qt.DeepEquals, menuKeyNames(s1))
+	c.Assert(menuKeyNames(m.Shuffle(33)), qt.Not(qt.DeepEquals), menuKeyNames(s1))
+	c.Assert(menuKeyNames(s1.ByIdentifier()), qt.DeepEquals, menuKeyNames(m.ByIdentifier()))
+	c.Assert(menuKeyNames(m), qt.DeepEquals, original)
+	c.Assert(menuKeyNames(m[0].Children), qt.DeepEquals, []string{"c1", "c2"})
+}
+
+func TestMenuEntryURLHasPrefix(t *testing.T) {
+	c := qt.New(t)
+
+	for _, test := range []struct {
+		url    string
+		prefix string
+		expect bool
+	}{
+		{"/docs/intro/", "/docs", true},
+		{"/docs/intro/", "/docs/", true},
+		{"/docs", "/docs/", true},
+		{"/docs/", "/docs", true},
+		{"/docs?q=1", "/docs/", true},
+		{"/docsy/", "/docs", false},
+		{"/blog/", "/docs", false},
+		{"/blog/", "/", true},
+		{"/", "/", true},
+		{"https://example.org/docs/", "/", false},
+		{"https://example.org/docs/", "https://example.org", true},
+		{"/docs/", "", false},
+		{"", "/", false},
+	} {
+		me := &MenuEntry{ConfiguredURL: test.url}
+		c.Assert(me.URLHasPrefix(test.prefix), qt.Equals, test.expect, qt.Commentf("%s %s", test.url, test.prefix))
+	}
+}
+
+func TestMenuByNameCollated(t *testing.T) {
+	c := qt.New(t)
+
+	m := Menu{
+		{Name: "Zebra"},
+		{Name: "Äpple"},
+		{Name: "Apple"},
+		{Name: "été"},
+		{Name: "Euro"},
+	}
+
+	c.Assert(menuKeyNames(m.ByNameCollated("sv")), qt.DeepEquals, []string{"Apple", "été", "Euro", "Zebra", "Äpple"})
+	c.Assert(menuKeyNames(m.ByNameCollated("en")), qt.DeepEquals, []string{"Apple", "Äpple", "été", "Euro", "Zebra"})
+	c.Assert(menuKeyNames(m.ByNameCollated("invalid!")), qt.DeepEquals, menuKeyNames(m.ByNameCollated("en")))
+}
+
+func TestMenuUnionIntersect(t *testing.T) {
+	c := qt.New(t)
+
+	m1 := Menu{{Identifier: "a", Name: "A1"}, {Identifier: "b"}, {Identifier: "c"}}
+	m2 := Menu{{Identifier: "d"}, {Identifier: "a", Name: "A2"}, {Identifier: "c"}, {Identifier: "d"}}
+
+	union := m1.Union(m2)
+	c.Assert(menuKeyNames(union), qt.DeepEquals, []string{"a", "b", "c", "d"})
+	c.Assert(union[0].Name, qt.Equals, "A1")
+
+	intersection := m1.Intersect(m2)
+	c.Assert(menuKeyNames(intersection), qt.DeepEquals, []string{"a", "c"})
+	c.Assert(intersection[0], qt.Equals, m1[0])
+
+	c.Assert(menuKeyNames(m1), qt.DeepEquals, []string{"a", "b", "c"})
+	c.Assert(m1.Intersect(nil), qt.HasLen, 0)
+	c.Assert(menuKeyNames(Menu(nil).Union(m1)), qt.DeepEquals, []string{"a", "b", "c"})
+}
+
+func TestMenuEntryWithParam(t *testing.T) {
+	c := qt.New(t)
+
+	me := &MenuEntry{Identifier: "a", Params: maps.Params{"icon": "book"}, Children: Menu{{Identifier: "a1"}}}
+
+	me2 := me.WithParam("Active", true)
+	c.Assert(me2, qt.Not(qt.Equals), me)
+	c.Assert(me2.ParamBool("active"), qt.IsTrue)
+	c.Assert(me2.ParamString("icon"), qt.Equals, "book")
+	c.Assert(me2.Children, qt.HasLen, 1)
+	c.Assert(me.Params, qt.DeepEquals, maps.Params{"icon": "book"})
+
+	c.Assert(me.WithParam("icon", "star").ParamString("icon"), qt.Equals, "star")
+	c.Assert(me.ParamString("icon"), qt.Equals, "book")
+	c.Assert((&MenuEntry{}).WithParam("a", 1).ParamInt("a"), qt.Equals, 1)
+
+	m := Menu{me, {Identifier: "b"}}
+	m.InitTree()
+	me3 := me.Children[0].WithParam("x", 1)
+	c.Assert(me3.ParentEntry(), qt.IsNil)
+	c.Assert(me.WithParam("x", 1).NextSibling(), qt.IsNil)
+	c.Assert(me.NextSibling(), qt.Equals, m[1])
+}
+
+type linkTitleCountingPage struct {
+	*testPage
+	count int
+}
+
+func (p *linkTitleCountingPage) LinkTitle() string {
+	p.count++
+	return p.testPage.LinkTitle()
+}
+
+func TestMenuEntryTitleCache(t *testing.T) {
+	c := qt.New(t)
+
+	p1 := &linkTitleCountingPage{testPage: newTestPage("/docs/p1")}
+	p2 := &linkTitleCountingPage{testPage: newTestPage("/docs/p2")}
+
+	me := &MenuEntry{}
+	me.SetPage(p1)
+	c.Assert(p1.count, qt.Equals, 1)
+	for i := 0; i < 3; i++ {
+		c.Assert(me.Title(), qt.Equals, "/docs/p1")
+	}
+	c.Assert(p1.count, qt.Equals, 1)
+
+	// Replacing the page invalidates the cache.
+	me.SetPage(p2)
+	c.Assert(me.Title(), qt.Equals, "/docs/p2")
+	me.Page = p1
+	c.Assert(me.Title(), qt.Equals, "/docs/p1")
+	c.Assert(p1.count, qt.Equals, 2)
+
+	// An explicitly set title takes precedence.
+	me.title = "Docs"
+	c.Assert(me.Title(), qt.Equals, "Docs")
+
+	// Entries with the page set directly are cached by InitTree.
+	p3 := &linkTitleCountingPage{testPage: newTestPage("/docs/p3")}
+	m := Menu{{Page: p3}}
+	c.Assert(m[0].Title(), qt.Equals, "/docs/p3")
+	m.InitTree()
+	c.Assert(m[0].Title(), qt.Equals, "/docs/p3")
+	c.Assert(p3.count, qt.Equals, 2)
+}
+
+func TestMenuEntryAliases(t *testing.T) {
+	c := qt.New(t)
+
+	var me MenuEntry
+	c.Assert(me.MarshallMap(map[string]any{
+		"url":     "/docs/",
+		"aliases": []any{"/documentation/", "/manual/"},
+	}), qt.IsNil)
+	c.Assert(me.Aliases, qt.DeepEquals, []string{"/documentation/", "/manual/"})
+
+	c.Assert(me.IsActive(newTestPage("/documentation")), qt.IsTrue)
+	c.Assert(me.IsActive(newTestPage("/docs")), qt.IsTrue)
+	c.Assert(me.IsActive(newTestPage("/blog")), qt.IsFalse)
+
+	c.Assert(me.IsSameResource(&MenuEntry{ConfiguredURL: "/manual/"}), qt.IsTrue)
+	c.Assert((&MenuEntry{ConfiguredURL: "/manual/"}).IsSameResource(&me), qt.IsTrue)
+	c.Assert(me.IsSameResource(&MenuEntry{ConfiguredURL: "/blog/", Aliases: []string{"/docs/"}}), qt.IsTrue)
+	c.Assert(me.IsSameResource(&MenuEntry{ConfiguredURL: "/blog/"}), qt.IsFalse)
+	c.Assert(me.IsSameResourceNormalized(&MenuEntry{ConfiguredURL: "/Manual"}), qt.IsTrue)
+	c.Assert((&MenuEntry{Aliases: []string{""}}).IsSameResource(&MenuEntry{}), qt.IsFalse)
+
+	c.Assert(me.MarshallMap(map[string]any{"aliases": map[string]any{"a": 1}}), qt.ErrorMatches, `failed to marshal menu entry .*: cannot convert map\[string\]interface {} to aliases`)
+}
+
+type relPermalinkPage struct {
+	*testPage
+	relPermalink string
+}
+
+func (p *relPermalinkPage) RelPermalink() string {
+	return p.relPermalink
+}
+
+func TestMenuEntryIsActiveIgnoringQuery(t *testing.T) {
+	c := qt.New(t)
+
+	search := newTestPage("/search")
+	searchWithQuery := &relPermalinkPage{testPage: newTestPage("/search"), relPermalink: "/search/?q=x#results"}
+
+	for _, test := range []struct {
+		url    string
+		p      Page
+		expect bool
+	}{
+		{"/search/", search, true},
+		{"/search/?q=hugo", search, true},
+		{"/search/#results", search, true},
+		{"/search/?q=hugo#results", search, true},
+		{"/search/", searchWithQuery, true},
+		{"/search/?q=y", searchWithQuery, true},
+		{"/search/#top", searchWithQuery, true},
+		{"/searchx/?q=hugo", search, false},
+		{"?q=hugo", search, false},
+		{"#results", search, false},
+		{"", search, false},
+		{"/search/", nil, false},
+	} {
+		me := &MenuEntry{ConfiguredURL: test.url}
+		c.Assert(me.IsActiveIgnoringQuery(test.p), qt.Equals, test.expect, qt.Commentf(test.url))
+	}
+
+	c.Assert((&MenuEntry{ConfiguredURL: "/search/?q=hugo"}).IsActive(search), qt.IsFalse)
+	c.Assert((&MenuEntry{Page: search}).IsActiveIgnoringQuery(search), qt.IsTrue)
+}
+
+func TestMenuEntryIsAncestorOf(t *testing.T) {
+	c := qt.New(t)
+
+	docs, p1, blog := newTestPage("/docs"), newTestPage("/docs/p1"), newTestPage("/blog")
+	isAncestorOf := func(me *MenuEntry, p Page) bool {
+		b, err := me.IsAncestorOf(p)
+		c.Assert(err, qt.IsNil)
+		return b
+	}
+
+	c.Assert(isAncestorOf(&MenuEntry{Page: docs}, p1), qt.IsTrue)
+	c.Assert(isAncestorOf(&MenuEntry{Page: docs}, docs), qt.IsFalse)
+	c.Assert(isAncestorOf(&MenuEntry{Page: blog}, p1), qt.IsFalse)
+	c.Assert(isAncestorOf(&MenuEntry{Page: p1}, docs), qt.IsFalse)
+
+	// Nil pages and entries not connected to a page.
+	var nilPage *testPage
+	c.Assert(isAncestorOf(&MenuEntry{Page: docs}, nil), qt.IsFalse)
+	c.Assert(isAncestorOf(&MenuEntry{Page: docs}, nilPage), qt.IsFalse)
+	c.Assert(isAncestorOf(&MenuEntry{ConfiguredURL: "/docs/"}, p1), qt.IsFalse)
+	c.Assert(isAncestorOf(&MenuEntry{Page: nilPage}, p1), qt.IsFalse)
+}
+
+func TestMenuSortFunc(t *testing.T) {
+	c := qt.New(t)
+
+	m := Menu{
+		{Identifier: "b2", Name: "b", Weight: 2},
+		{Identifier: "a1", Name: "a", Weight: 1},
+		{Identifier: "b1", Name: "b", Weight: 1},
+		{Identifier: "a2", Name: "a", Weight: 2},
+	}
+	first := &m[0]
+
+	sorted := m.SortFunc(func(a, b *MenuEntry) bool { return a.Name < b.Name })
+
+	// Sorted in place and stable.
+	c.Assert(&sorted[0], qt.Equals, first)
+	c.Assert(menuKeyNames(m), qt.DeepEquals, []string{"a1", "a2", "b2", "b1"})
+
+	c.Assert(menuKeyNames(m.SortFunc(func(a, b *MenuEntry) bool { return a.Weight < b.Weight })), qt.DeepEquals, []string{"a1", "b1", "a2", "b2"})
+	c.Assert(Menu(nil).SortFunc(func(a, b *MenuEntry) bool { return false }), qt.IsNil)
+}
+
+func TestMenuFind(t *testing.T) {
+	c := qt.New(t)
+
+	m := Menu{
+		{Identifier: "a", Children: Menu{{Identifier: "a1"}}},
+		{Name: "b"},
+		{Identifier: "a", Name: "second"},
+	}
+
+	c.Assert(m.Find("a"), qt.Equals, m[0])
+	c.Assert(m.Find("b"), qt.Equals, m[1])
+
+	// Top level only.
+	c.Assert(m.Find("a1"), qt.IsNil)
+	c.Assert(m.Find("c"), qt.IsNil)
+	c.Assert(m.Find(""), qt.IsNil)
+	c.Assert(Menu(nil).Find("a"), qt.IsNil)
+}
+
+func TestMenuContains(t *testing.T) {
+	c := qt.New(t)
+
+	m := Menu{
+		{Identifier: "a", Children: Menu{{Identifier: "a1", Parent: "a"}}},
+		{Name: "b", ConfiguredURL: "/b/"},
+	}
+
+	c.Assert(m.Contains(m[0]), qt.IsTrue)
+	c.Assert(m.Contains(&MenuEntry{Identifier: "a", Name: "other"}), qt.IsTrue)
+	c.Assert(m.Contains(&MenuEntry{ConfiguredURL: "/b/"}), qt.IsTrue)
+
+	c.Assert(m.Contains(&MenuEntry{Identifier: "c"}), qt.IsFalse)
+	c.Assert(m.Contains(&MenuEntry{Identifier: "a", Parent: "c"}), qt.IsFalse)
+	// Top level only.
+	c.Assert(m.Contains(m[0].Children[0]), qt.IsFalse)
+	c.Assert(m.Contains(nil), qt.IsFalse)
+	c.Assert(Menu(nil).Contains(m[0]), qt.IsFalse)
+}
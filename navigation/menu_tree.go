@@ -0,0 +1,604 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package navigation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gohugoio/hugo/common/maps"
+	"github.com/gohugoio/hugo/common/types"
+
+	"github.com/pkg/errors"
+)
+
+// Walk walks the menu tree depth first in menu order, calling fn for every
+// entry with its depth, 0 for the top level entries.
+// The children of an entry are visited before its next sibling.
+// The walk stops when fn returns false.
+func (m Menu) Walk(fn func(e *MenuEntry, depth int) bool) {
+	m.walk(fn)
+}
+
+// walk walks the menu tree depth first, calling fn for every entry.
+// The walk stops when fn returns false.
+// An entry is visited only once, which protects against cycles in
+// malformed menus.
+func (m Menu) walk(fn func(me *MenuEntry, depth int) bool) {
+	m.walkDepth(0, make(map[*MenuEntry]bool), fn)
+}
+
+func (m Menu) walkDepth(depth int, visited map[*MenuEntry]bool, fn func(me *MenuEntry, depth int) bool) bool {
+	for _, me := range m {
+		if visited[me] {
+			continue
+		}
+		visited[me] = true
+		if !fn(me, depth) {
+			return false
+		}
+		if !me.Children.walkDepth(depth+1, visited, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// InitTree sets up the references from the entries in the menu tree
+// to their parent entries and siblings, and caches the title of the
+// connected pages, see MenuEntry.Title.
+// Hugo does this for the assembled site menus, and the methods returning
+// new menu trees, e.g. Enabled and CloneDeep, for the trees they return,
+// but menus built in Go code must call it before using e.g.
+// MenuEntry.Depth and MenuEntry.ParentEntry.
+// Note that this modifies the entries in m.
+func (m Menu) InitTree() {
+	m.initTree(nil, make(map[*MenuEntry]bool))
+}
+
+func (m Menu) initTree(parent *MenuEntry, visited map[*MenuEntry]bool) {
+	for _, me := range m {
+		if visited[me] {
+			continue
+		}
+		visited[me] = true
+		me.parent = parent
+		me.siblings = m
+		me.cacheLinkTitle()
+		me.Children.initTree(me, visited)
+	}
+}
+
+// Depth returns the 0-based nesting level of this entry in its menu tree,
+// 0 for a top level entry. See Level for the 1-based variant.
+// The depth is determined by where the entry is placed when the menu is
+// assembled and not by the Parent identifier alone: Hugo places an entry with
+// Parent set below that parent, so it is never a top level entry, and
+// an entry with a Parent set in a menu not assembled by Hugo is
+// reported at the level it is found.
+// The depth is 0 for entries in menus not set up with Menu.InitTree.
+func (m *MenuEntry) Depth() int {
+	var depth int
+	visited := map[*MenuEntry]bool{m: true}
+	for p := m.parent; p != nil && !visited[p]; p = p.parent {
+		visited[p] = true
+		depth++
+	}
+	return depth
+}
+
+// Level returns the 1-based nesting level of this entry in its menu tree,
+// 1 for a top level entry.
+// This is Depth plus one.
+func (m *MenuEntry) Level() int {
+	return m.Depth() + 1
+}
+
+// ParentEntry returns the entry this entry is placed below in the menu tree,
+// or nil for a top level entry.
+// As with Depth, this is set up with Menu.InitTree.
+func (m *MenuEntry) ParentEntry() *MenuEntry {
+	return m.parent
+}
+
+// NextSibling returns the entry after this entry on the same level in the
+// menu tree, or nil if this is the last entry.
+// The order is the order of the tree set up with Menu.InitTree, i.e. the
+// assembled menu; see Menu.NextSibling for a menu sorted differently,
+// e.g. with ByName.
+func (m *MenuEntry) NextSibling() *MenuEntry {
+	if i := m.siblingIndex(); i != -1 && i < len(m.siblings)-1 {
+		return m.siblings[i+1]
+	}
+	return nil
+}
+
+// PrevSibling returns the entry before this entry on the same level in the
+// menu tree, or nil if this is the first entry.
+// The order is the order of the tree set up with Menu.InitTree, see
+// Menu.PrevSibling.
+func (m *MenuEntry) PrevSibling() *MenuEntry {
+	if i := m.siblingIndex(); i > 0 {
+		return m.siblings[i-1]
+	}
+	return nil
+}
+
+// NextSibling returns the entry after me on the same level in the menu tree m,
+// in the order of m, or nil if me is the last entry or not in m.
+// Unlike MenuEntry.NextSibling, this respects the current sort order of m,
+// e.g. the result of ByName, or the result of ByName on the children.
+func (m Menu) NextSibling(me *MenuEntry) *MenuEntry {
+	siblings, i := m.findSiblings(me)
+	if i != -1 && i < len(siblings)-1 {
+		return siblings[i+1]
+	}
+	return nil
+}
+
+// PrevSibling returns the entry before me on the same level in the menu tree m,
+// in the order of m, or nil if me is the first entry or not in m.
+// See NextSibling.
+func (m Menu) PrevSibling(me *MenuEntry) *MenuEntry {
+	siblings, i := m.findSiblings(me)
+	if i > 0 {
+		return siblings[i-1]
+	}
+	return nil
+}
+
+// findSiblings returns the list of entries in the menu tree holding me
+// and the index of me in it, or -1 if not found.
+func (m Menu) findSiblings(me *MenuEntry) (Menu, int) {
+	if i := indexOfEntry(m, me); i != -1 {
+		return m, i
+	}
+	var siblings Menu
+	i := -1
+	m.walk(func(e *MenuEntry, depth int) bool {
+		if j := indexOfEntry(e.Children, me); j != -1 {
+			siblings, i = e.Children, j
+			return false
+		}
+		return true
+	})
+	return siblings, i
+}
+
+func indexOfEntry(m Menu, me *MenuEntry) int {
+	for i, e := range m {
+		if e == me {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *MenuEntry) siblingIndex() int {
+	return indexOfEntry(m.siblings, m)
+}
+
+// FindDeep returns the first entry in the menu tree with the given identifier
+// (see KeyName), searching depth first, or nil if not found.
+func (m Menu) FindDeep(identifier string) *MenuEntry {
+	var found *MenuEntry
+	m.walk(func(me *MenuEntry, depth int) bool {
+		if me.KeyName() == identifier {
+			found = me
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// Flatten returns a new menu with all the entries in the menu tree,
+// in depth first order.
+// The entries keep their Children.
+func (m Menu) Flatten() Menu {
+	flat := Menu{}
+	m.walk(func(me *MenuEntry, depth int) bool {
+		flat = append(flat, me)
+		return true
+	})
+	return flat
+}
+
+// Breadcrumb returns the entries from the top level down to and including
+// the first entry active for the given page (see IsActive), or an empty menu
+// if the page is not in this menu.
+func (m Menu) Breadcrumb(p Page) Menu {
+	return m.trail(func(me *MenuEntry) bool {
+		return me.IsActive(p)
+	})
+}
+
+// ActiveTrail is like Breadcrumb, but also checks that the trail is consistent
+// with the Parent identifiers of the entries in it, returning an error naming
+// the offending entry if not.
+func (m Menu) ActiveTrail(p Page) (Menu, error) {
+	trail := m.Breadcrumb(p)
+	for i, me := range trail {
+		if me.Parent == "" {
+			continue
+		}
+		if i == 0 {
+			return nil, errors.Errorf("menu entry %q: parent %q not found", me.KeyName(), me.Parent)
+		}
+		if parent := trail[i-1]; me.Parent != parent.KeyName() {
+			return nil, errors.Errorf("menu entry %q: parent %q does not match the entry it is placed below, %q", me.KeyName(), me.Parent, parent.KeyName())
+		}
+	}
+	return trail, nil
+}
+
+// FirstActiveAncestor returns the top level entry of the trail to the entry
+// active for the given page (see Breadcrumb), which is the active entry itself
+// if it is a top level entry, or nil if the page is not in this menu.
+func (m Menu) FirstActiveAncestor(p Page) *MenuEntry {
+	trail := m.Breadcrumb(p)
+	if len(trail) == 0 {
+		return nil
+	}
+	return trail[0]
+}
+
+// trail returns the path from the top level down to the first entry matching match.
+func (m Menu) trail(match func(me *MenuEntry) bool) Menu {
+	var stack Menu
+	trail := Menu{}
+	m.walk(func(me *MenuEntry, depth int) bool {
+		stack = append(stack[:depth], me)
+		if match(me) {
+			trail = append(trail, stack...)
+			return false
+		}
+		return true
+	})
+	return trail
+}
+
+// filterDeep returns a new menu tree with the entries for which keep returns true.
+// The children of an entry are filtered before keep is called for the entry.
+// The entries returned are shallow copies set up with InitTree, so m is left
+// untouched.
+func (m Menu) filterDeep(keep func(me *MenuEntry) bool) Menu {
+	filtered := m.filterDeepAncestors(keep, make(map[*MenuEntry]bool))
+	filtered.InitTree()
+	return filtered
+}
+
+// filterDeepAncestors filters m and its descendants, ancestors holds the
+// entries on the path from the top level down to m.
+func (m Menu) filterDeepAncestors(keep func(me *MenuEntry) bool, ancestors map[*MenuEntry]bool) Menu {
+	filtered := Menu{}
+	for _, me := range m {
+		if ancestors[me] {
+			continue
+		}
+
+		c := *me
+		if me.HasChildren() {
+			ancestors[me] = true
+			c.Children = me.Children.filterDeepAncestors(keep, ancestors)
+			delete(ancestors, me)
+			if len(c.Children) == 0 {
+				c.Children = nil
+			}
+		}
+
+		if keep(&c) {
+			filtered = append(filtered, &c)
+		}
+	}
+	return filtered
+}
+
+// WithoutOrphans returns a new menu tree without the entries not connected to
+// a Page, without an URL and without any children left, typically entries
+// with a PageRef that could not be resolved.
+func (m Menu) WithoutOrphans() Menu {
+	return m.filterDeep(func(me *MenuEntry) bool {
+		return !types.IsNil(me.Page) || me.URL() != "" || me.HasChildren()
+	})
+}
+
+// RemoveByIdentifier returns a new menu tree without the entries with the given
+// identifier (see MenuEntry.KeyName), including their children, on any level.
+// m is left untouched; if no entry matches, a copy of m is returned.
+func (m Menu) RemoveByIdentifier(id string) Menu {
+	return m.filterDeep(func(me *MenuEntry) bool {
+		return me.KeyName() != id
+	})
+}
+
+// Enabled returns a new menu tree without the disabled entries.
+// The disabled entries are still available in m.
+func (m Menu) Enabled() Menu {
+	return m.filterDeep(func(me *MenuEntry) bool {
+		return !me.Disabled
+	})
+}
+
+// Visible returns a new menu tree without the hidden and disabled entries.
+// A hidden entry is meant to be left out of visible navigation only,
+// but is still included when ranging over the menu itself (e.g. in a sitemap),
+// while a disabled entry should not be rendered at all, see Enabled.
+func (m Menu) Visible() Menu {
+	return m.filterDeep(func(me *MenuEntry) bool {
+		return !me.Disabled && !me.Hidden
+	})
+}
+
+// Distinct returns a new menu tree without duplicate entries (see MenuEntry.IsEqual)
+// within each list of entries, keeping the first occurrence.
+// The same entry may be used in several lists, but an entry placed below
+// itself, i.e. a cycle in a malformed menu, is left out.
+// The entries returned are shallow copies set up with InitTree, so m is left
+// untouched.
+func (m Menu) Distinct() Menu {
+	distinct := m.distinct(make(map[*MenuEntry]bool))
+	distinct.InitTree()
+	return distinct
+}
+
+// distinct dedupes m and its descendants, ancestors holds the entries
+// on the path from the top level down to m.
+func (m Menu) distinct(ancestors map[*MenuEntry]bool) Menu {
+	result := Menu{}
+	for _, me := range m {
+		if ancestors[me] || result.Contains(me) {
+			continue
+		}
+
+		c := *me
+		if me.HasChildren() {
+			ancestors[me] = true
+			c.Children = me.Children.distinct(ancestors)
+			delete(ancestors, me)
+			if len(c.Children) == 0 {
+				c.Children = nil
+			}
+		}
+
+		result = append(result, &c)
+	}
+	return result
+}
+
+// Count returns the number of entries in the menu tree, including all descendants.
+func (m Menu) Count() int {
+	var count int
+	m.walk(func(me *MenuEntry, depth int) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+// CountLeaves returns the number of entries in the menu tree without any children.
+func (m Menu) CountLeaves() int {
+	var count int
+	m.walk(func(me *MenuEntry, depth int) bool {
+		if len(me.Children) == 0 {
+			count++
+		}
+		return true
+	})
+	return count
+}
+
+// MaxDepth returns the deepest nesting level in the menu tree, where a menu
+// with top level entries only has a MaxDepth of 1.
+// It returns 0 for an empty menu.
+func (m Menu) MaxDepth() int {
+	var maxDepth int
+	m.walk(func(me *MenuEntry, depth int) bool {
+		if depth+1 > maxDepth {
+			maxDepth = depth + 1
+		}
+		return true
+	})
+	return maxDepth
+}
+
+// Prune returns a new menu tree where entries at maxDepth (see MenuEntry.Depth)
+// have no children, so 0 returns the top level entries only.
+// The entries returned are shallow copies set up with InitTree, so m is left
+// untouched.
+func (m Menu) Prune(maxDepth int) Menu {
+	pruned := m.prune(0, maxDepth, make(map[*MenuEntry]bool))
+	pruned.InitTree()
+	return pruned
+}
+
+// prune prunes m and its descendants, ancestors holds the entries
+// on the path from the top level down to m.
+func (m Menu) prune(depth, maxDepth int, ancestors map[*MenuEntry]bool) Menu {
+	pruned := make(Menu, 0, len(m))
+	for _, me := range m {
+		if ancestors[me] {
+			continue
+		}
+
+		c := *me
+		if me.HasChildren() {
+			c.Children = nil
+			if depth < maxDepth {
+				ancestors[me] = true
+				c.Children = me.Children.prune(depth+1, maxDepth, ancestors)
+				delete(ancestors, me)
+			}
+		}
+		pruned = append(pruned, &c)
+	}
+	return pruned
+}
+
+// SortRecursive returns a new menu tree with the entries on all levels sorted
+// by weight, name and then identifier, see Sort.
+// The entries returned are shallow copies set up with InitTree, so m, e.g. a
+// site menu shared by templates rendering in parallel, is left untouched.
+func (m Menu) SortRecursive() Menu {
+	sorted := m.sortRecursive(make(map[*MenuEntry]bool))
+	sorted.InitTree()
+	return sorted
+}
+
+// sortRecursive sorts copies of m and its descendants, ancestors holds the
+// entries on the path from the top level down to m.
+func (m Menu) sortRecursive(ancestors map[*MenuEntry]bool) Menu {
+	sorted := make(Menu, 0, len(m))
+	for _, me := range m {
+		if ancestors[me] {
+			continue
+		}
+
+		c := *me
+		if me.HasChildren() {
+			ancestors[me] = true
+			c.Children = me.Children.sortRecursive(ancestors)
+			delete(ancestors, me)
+		}
+		sorted = append(sorted, &c)
+	}
+	return sorted.Sort()
+}
+
+// Equals returns whether m and other have the same entries in the same order,
+// see MenuEntry.IsEqual, including all their descendants.
+func (m Menu) Equals(other Menu) bool {
+	return m.equals(other, make(map[*MenuEntry]bool))
+}
+
+func (m Menu) equals(other Menu, visited map[*MenuEntry]bool) bool {
+	if len(m) != len(other) {
+		return false
+	}
+	for i, me := range m {
+		if !me.IsEqual(other[i]) {
+			return false
+		}
+		if visited[me] {
+			continue
+		}
+		visited[me] = true
+		if !me.Children.equals(other[i].Children, visited) {
+			return false
+		}
+	}
+	return true
+}
+
+// CloneDeep returns a deep copy of the menu tree, with copies of all the entries,
+// their children and their params, so the copy can be modified without affecting m.
+// See Clone for a shallow copy of the top level.
+func (m Menu) CloneDeep() Menu {
+	clone := m.cloneDeep(make(map[*MenuEntry]*MenuEntry))
+	clone.InitTree()
+	return clone
+}
+
+func (m Menu) cloneDeep(clones map[*MenuEntry]*MenuEntry) Menu {
+	if m == nil {
+		return nil
+	}
+	clone := make(Menu, len(m))
+	for i, me := range m {
+		if c, found := clones[me]; found {
+			clone[i] = c
+			continue
+		}
+		c := *me
+		clones[me] = &c
+		c.Params = cloneParams(me.Params)
+		c.Children = me.Children.cloneDeep(clones)
+		clone[i] = &c
+	}
+	return clone
+}
+
+// cloneParams returns a copy of p, including any nested params.
+func cloneParams(p maps.Params) maps.Params {
+	if p == nil {
+		return nil
+	}
+	clone := make(maps.Params, len(p))
+	for k, v := range p {
+		if vv, ok := v.(maps.Params); ok {
+			v = cloneParams(vv)
+		}
+		clone[k] = v
+	}
+	return clone
+}
+
+// InheritParams returns a copy of the menu tree where the entries inherit the
+// params of their parent entries, so a param set on an entry applies to all its
+// descendants unless they set the same param themselves.
+// The params are merged on the top level only, and m is left untouched.
+func (m Menu) InheritParams() Menu {
+	clone := m.inheritParams(nil, make(map[*MenuEntry]bool))
+	clone.InitTree()
+	return clone
+}
+
+// inheritParams merges parentParams into copies of m and its descendants,
+// ancestors holds the entries on the path from the top level down to m.
+func (m Menu) inheritParams(parentParams maps.Params, ancestors map[*MenuEntry]bool) Menu {
+	if m == nil {
+		return nil
+	}
+	result := make(Menu, 0, len(m))
+	for _, me := range m {
+		if ancestors[me] {
+			continue
+		}
+
+		c := *me
+		if len(parentParams) > 0 {
+			c.Params = cloneParams(me.Params)
+			if c.Params == nil {
+				c.Params = make(maps.Params)
+			}
+			for k, v := range parentParams {
+				if _, found := c.Params[k]; !found {
+					c.Params[k] = v
+				}
+			}
+		}
+		ancestors[me] = true
+		c.Children = me.Children.inheritParams(c.Params, ancestors)
+		delete(ancestors, me)
+		if len(c.Children) == 0 {
+			c.Children = nil
+		}
+		result = append(result, &c)
+	}
+	return result
+}
+
+// Tree returns a text representation of the menu tree, meant for debugging,
+// with one entry per line indented by its depth, e.g.
+//
+//	docs|weight:10|url:/docs/
+//	  intro|weight:0|url:/docs/intro/
+func (m Menu) Tree() string {
+	var b strings.Builder
+	m.walk(func(me *MenuEntry, depth int) bool {
+		fmt.Fprintf(&b, "%s%s|weight:%d|url:%s\n", strings.Repeat("  ", depth), me.KeyName(), me.Weight, me.URL())
+		return true
+	})
+	return b.String()
+}
@@ -0,0 +1,560 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package navigation
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/gohugoio/hugo/common/maps"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// createTreeTestMenu creates
+//
+//	a
+//	  a1
+//	    a11
+//	  a2
+//	b
+func createTreeTestMenu() Menu {
+	a11 := &MenuEntry{Identifier: "a11", Parent: "a1"}
+	a1 := &MenuEntry{Identifier: "a1", Parent: "a", Children: Menu{a11}}
+	a2 := &MenuEntry{Identifier: "a2", Parent: "a"}
+	a := &MenuEntry{Identifier: "a", Children: Menu{a1, a2}}
+	b := &MenuEntry{Identifier: "b"}
+	return Menu{a, b}
+}
+
+func TestMenuFindDeep(t *testing.T) {
+	c := qt.New(t)
+
+	m := createTreeTestMenu()
+
+	c.Assert(m.FindDeep("a11"), qt.Not(qt.IsNil))
+	c.Assert(m.FindDeep("a11").Identifier, qt.Equals, "a11")
+	c.Assert(m.FindDeep("b").Identifier, qt.Equals, "b")
+	c.Assert(m.FindDeep("c"), qt.IsNil)
+
+	// Create a cycle.
+	a11 := m.FindDeep("a11")
+	a11.Children = Menu{m[0]}
+	c.Assert(m.FindDeep("c"), qt.IsNil)
+}
+
+func TestMenuEntryDepth(t *testing.T) {
+	c := qt.New(t)
+
+	m := createTreeTestMenu()
+	m.InitTree()
+
+	c.Assert(m.FindDeep("a").Depth(), qt.Equals, 0)
+	c.Assert(m.FindDeep("a1").Depth(), qt.Equals, 1)
+	c.Assert(m.FindDeep("a11").Depth(), qt.Equals, 2)
+	c.Assert(m.FindDeep("a2").Depth(), qt.Equals, 1)
+	c.Assert(m.FindDeep("b").Depth(), qt.Equals, 0)
+	c.Assert(m.FindDeep("a").Level(), qt.Equals, 1)
+	c.Assert(m.FindDeep("a11").Level(), qt.Equals, 3)
+}
+
+func TestMenuEntryChildCount(t *testing.T) {
+	c := qt.New(t)
+
+	m := createTreeTestMenu()
+	a, a1, b := m.FindDeep("a"), m.FindDeep("a1"), m.FindDeep("b")
+
+	c.Assert(a.ChildCount(), qt.Equals, 2)
+	c.Assert(a.HasGrandchildren(), qt.IsTrue)
+	c.Assert(a1.ChildCount(), qt.Equals, 1)
+	c.Assert(a1.HasGrandchildren(), qt.IsFalse)
+	c.Assert(b.ChildCount(), qt.Equals, 0)
+	c.Assert(b.HasGrandchildren(), qt.IsFalse)
+}
+
+func TestMenuEntryParentEntry(t *testing.T) {
+	c := qt.New(t)
+
+	m := createTreeTestMenu()
+	m.InitTree()
+
+	c.Assert(m.FindDeep("a").ParentEntry(), qt.IsNil)
+	c.Assert(m.FindDeep("b").ParentEntry(), qt.IsNil)
+	c.Assert(m.FindDeep("a1").ParentEntry(), qt.Equals, m.FindDeep("a"))
+	c.Assert(m.FindDeep("a11").ParentEntry(), qt.Equals, m.FindDeep("a1"))
+	c.Assert(m.FindDeep("a11").ParentEntry().ParentEntry(), qt.Equals, m.FindDeep("a"))
+}
+
+func TestMenuFlatten(t *testing.T) {
+	c := qt.New(t)
+
+	m := createTreeTestMenu()
+	flat := m.Flatten()
+
+	c.Assert(menuKeyNames(flat), qt.DeepEquals, []string{"a", "a1", "a11", "a2", "b"})
+	c.Assert(flat[0].Children, qt.HasLen, 2)
+	c.Assert(m, qt.HasLen, 2)
+}
+
+func TestMenuEntryHasActiveChild(t *testing.T) {
+	c := qt.New(t)
+
+	p := newTestPage("/docs/p1")
+	m := createTreeTestMenu()
+	m.FindDeep("a11").Page = p
+
+	c.Assert(m.FindDeep("a").HasActiveChild(p), qt.IsTrue)
+	c.Assert(m.FindDeep("a1").HasActiveChild(p), qt.IsTrue)
+	c.Assert(m.FindDeep("a11").HasActiveChild(p), qt.IsFalse)
+	c.Assert(m.FindDeep("b").HasActiveChild(p), qt.IsFalse)
+}
+
+func TestMenuBreadcrumb(t *testing.T) {
+	c := qt.New(t)
+
+	p := newTestPage("/docs/p1")
+	m := createTreeTestMenu()
+
+	c.Assert(m.Breadcrumb(p), qt.HasLen, 0)
+
+	m.FindDeep("a11").Page = p
+	c.Assert(menuKeyNames(m.Breadcrumb(p)), qt.DeepEquals, []string{"a", "a1", "a11"})
+
+	m.FindDeep("a11").Page = nil
+	m.FindDeep("a2").ConfiguredURL = p.RelPermalink()
+	c.Assert(menuKeyNames(m.Breadcrumb(p)), qt.DeepEquals, []string{"a", "a2"})
+}
+
+func TestMenuWithoutOrphans(t *testing.T) {
+	c := qt.New(t)
+
+	m := createTreeTestMenu()
+	m.FindDeep("a11").Page = newTestPage("/docs/p1")
+	m.FindDeep("b").ConfiguredURL = "/b/"
+
+	clean := m.WithoutOrphans()
+
+	c.Assert(menuKeyNames(clean.Flatten()), qt.DeepEquals, []string{"a", "a1", "a11", "b"})
+	c.Assert(menuKeyNames(m.Flatten()), qt.DeepEquals, []string{"a", "a1", "a11", "a2", "b"})
+	c.Assert(clean[1], qt.Not(qt.Equals), m[1])
+	c.Assert(clean[1].IsEqual(m[1]), qt.IsTrue)
+
+	c.Assert(Menu{{Identifier: "x", PageRef: "/x"}}.WithoutOrphans(), qt.HasLen, 0)
+}
+
+func TestMenuFilterDeepGrandchildren(t *testing.T) {
+	c := qt.New(t)
+
+	m := createTreeTestMenu()
+	for _, me := range m.Flatten() {
+		me.ConfiguredURL = "/" + me.Identifier + "/"
+	}
+
+	// Removing an entry at depth 2 must copy all of its ancestors.
+	m.FindDeep("a11").ConfiguredURL = ""
+	c.Assert(menuKeyNames(m.WithoutOrphans().Flatten()), qt.DeepEquals, []string{"a", "a1", "a2", "b"})
+
+	m.FindDeep("a11").ConfiguredURL = "/a11/"
+	m.FindDeep("a11").Disabled = true
+	c.Assert(menuKeyNames(m.Enabled().Flatten()), qt.DeepEquals, []string{"a", "a1", "a2", "b"})
+	c.Assert(menuKeyNames(m.Flatten()), qt.DeepEquals, []string{"a", "a1", "a11", "a2", "b"})
+}
+
+func TestMenuDistinct(t *testing.T) {
+	c := qt.New(t)
+
+	m := createTreeTestMenu()
+	a := m[0]
+	a.Children = append(a.Children, &MenuEntry{Identifier: "a1", Parent: "a"})
+	m = append(m, &MenuEntry{Identifier: "b"}, &MenuEntry{Identifier: "c"})
+
+	distinct := m.Distinct()
+
+	c.Assert(menuKeyNames(distinct), qt.DeepEquals, []string{"a", "b", "c"})
+	c.Assert(menuKeyNames(distinct[0].Children), qt.DeepEquals, []string{"a1", "a2"})
+	c.Assert(menuKeyNames(a.Children), qt.DeepEquals, []string{"a1", "a2", "a1"})
+}
+
+func TestMenuFilterBackReferences(t *testing.T) {
+	c := qt.New(t)
+
+	m := createTreeTestMenu()
+	m.InitTree()
+	m.FindDeep("a1").Disabled = true
+	a, a2 := m.FindDeep("a"), m.FindDeep("a2")
+
+	for _, filtered := range []Menu{m.Enabled(), m.Visible(), m.Prune(1), m.Distinct(), m.RemoveByIdentifier("a1")} {
+		fa := filtered[0]
+		c.Assert(fa, qt.Not(qt.Equals), a)
+		for _, child := range fa.Children {
+			c.Assert(child.ParentEntry(), qt.Equals, fa)
+			c.Assert(child.Depth(), qt.Equals, 1)
+		}
+		c.Assert(fa.NextSibling(), qt.Equals, filtered[1])
+		c.Assert(filtered[1].PrevSibling(), qt.Equals, fa)
+	}
+
+	enabled := m.Enabled()
+	c.Assert(menuKeyNames(enabled[0].Children), qt.DeepEquals, []string{"a2"})
+	c.Assert(enabled[0].Children[0].PrevSibling(), qt.IsNil)
+
+	// m is left untouched.
+	c.Assert(a2.ParentEntry(), qt.Equals, a)
+	c.Assert(a2.PrevSibling(), qt.Equals, m.FindDeep("a1"))
+}
+
+func TestMenuDistinctDeep(t *testing.T) {
+	c := qt.New(t)
+
+	// Duplicates at depth 2.
+	m := createTreeTestMenu()
+	a1 := m.FindDeep("a1")
+	a1.Children = append(a1.Children, &MenuEntry{Identifier: "a11", Parent: "a1"})
+	distinct := m.Distinct()
+	c.Assert(menuKeyNames(distinct[0].Children[0].Children), qt.DeepEquals, []string{"a11"})
+	c.Assert(a1.Children, qt.HasLen, 2)
+
+	// The same entry in two different lists is kept in both.
+	x := &MenuEntry{Identifier: "x"}
+	dm := Menu{{Identifier: "p", Children: Menu{x}}, {Identifier: "q", Children: Menu{x}}}
+	distinct = dm.Distinct()
+	c.Assert(menuKeyNames(distinct[0].Children), qt.DeepEquals, []string{"x"})
+	c.Assert(menuKeyNames(distinct[1].Children), qt.DeepEquals, []string{"x"})
+
+	// Cycles are left out.
+	m = createTreeTestMenu()
+	m.FindDeep("a11").Children = Menu{m[0]}
+	c.Assert(m.Distinct()[0].Children[0].Children[0].HasChildren(), qt.IsFalse)
+}
+
+func TestMenuRemoveByIdentifier(t *testing.T) {
+	c := qt.New(t)
+
+	m := createTreeTestMenu()
+
+	c.Assert(menuKeyNames(m.RemoveByIdentifier("a1").Flatten()), qt.DeepEquals, []string{"a", "a2", "b"})
+	c.Assert(menuKeyNames(m.RemoveByIdentifier("b").Flatten()), qt.DeepEquals, []string{"a", "a1", "a11", "a2"})
+	c.Assert(menuKeyNames(m.Flatten()), qt.DeepEquals, []string{"a", "a1", "a11", "a2", "b"})
+
+	same := m.RemoveByIdentifier("nope")
+	c.Assert(same.Equals(m), qt.IsTrue)
+	same[0] = &MenuEntry{Identifier: "x"}
+	c.Assert(m[0].Identifier, qt.Equals, "a")
+}
+
+func TestMenuEnabled(t *testing.T) {
+	c := qt.New(t)
+
+	m := createTreeTestMenu()
+	m.FindDeep("a1").Disabled = true
+	m.FindDeep("b").Disabled = true
+
+	c.Assert(menuKeyNames(m.Enabled().Flatten()), qt.DeepEquals, []string{"a", "a2"})
+	c.Assert(m.Flatten(), qt.HasLen, 5)
+}
+
+func TestMenuVisible(t *testing.T) {
+	c := qt.New(t)
+
+	m := createTreeTestMenu()
+	m.FindDeep("a11").Hidden = true
+	m.FindDeep("b").Disabled = true
+
+	c.Assert(menuKeyNames(m.Visible().Flatten()), qt.DeepEquals, []string{"a", "a1", "a2"})
+	c.Assert(menuKeyNames(m.Enabled().Flatten()), qt.DeepEquals, []string{"a", "a1", "a11", "a2"})
+}
+
+func TestMenuCount(t *testing.T) {
+	c := qt.New(t)
+
+	m := createTreeTestMenu()
+
+	c.Assert(m.Count(), qt.Equals, 5)
+	c.Assert(m.CountLeaves(), qt.Equals, 3)
+	c.Assert(Menu(nil).Count(), qt.Equals, 0)
+	c.Assert(Menu(nil).CountLeaves(), qt.Equals, 0)
+}
+
+func TestMenuMaxDepth(t *testing.T) {
+	c := qt.New(t)
+
+	m := createTreeTestMenu()
+
+	c.Assert(m.MaxDepth(), qt.Equals, 3)
+	c.Assert(m.Prune(1).MaxDepth(), qt.Equals, 2)
+	c.Assert(Menu{{Identifier: "a"}, {Identifier: "b"}}.MaxDepth(), qt.Equals, 1)
+	c.Assert(Menu(nil).MaxDepth(), qt.Equals, 0)
+}
+
+func TestMenuEntrySiblings(t *testing.T) {
+	c := qt.New(t)
+
+	m := createTreeTestMenu()
+	m.InitTree()
+
+	a, a1, a2, b := m.FindDeep("a"), m.FindDeep("a1"), m.FindDeep("a2"), m.FindDeep("b")
+
+	c.Assert(a.NextSibling(), qt.Equals, b)
+	c.Assert(a.PrevSibling(), qt.IsNil)
+	c.Assert(b.PrevSibling(), qt.Equals, a)
+	c.Assert(b.NextSibling(), qt.IsNil)
+	c.Assert(a1.NextSibling(), qt.Equals, a2)
+	c.Assert(a2.PrevSibling(), qt.Equals, a1)
+	c.Assert(m.FindDeep("a11").NextSibling(), qt.IsNil)
+	c.Assert((&MenuEntry{}).NextSibling(), qt.IsNil)
+}
+
+func TestMenuSiblingsInSortOrder(t *testing.T) {
+	c := qt.New(t)
+
+	m, err := NewMenuFromMaps([]map[string]any{
+		{"identifier": "a", "weight": 1},
+		{"identifier": "d", "weight": 2},
+		{"identifier": "b", "weight": 3},
+		{"identifier": "c", "weight": 4, "parent": "a"},
+		{"identifier": "e", "weight": 5, "parent": "a"},
+		{"identifier": "f", "weight": 6, "parent": "a"},
+	})
+	c.Assert(err, qt.IsNil)
+
+	sorted := m.ByIdentifier()
+	a, b, d := m.Find("a"), m.Find("b"), m.Find("d")
+	c.Assert(menuKeyNames(sorted), qt.DeepEquals, []string{"a", "b", "d"})
+	c.Assert(sorted.NextSibling(a), qt.Equals, b)
+	c.Assert(sorted.PrevSibling(d), qt.Equals, b)
+	c.Assert(sorted.NextSibling(d), qt.IsNil)
+	c.Assert(sorted.PrevSibling(a), qt.IsNil)
+	c.Assert(a.NextSibling(), qt.Equals, d)
+
+	a.Children = a.Children.ByWeightReverse()
+	e := m.FindDeep("e")
+	c.Assert(sorted.NextSibling(e), qt.Equals, m.FindDeep("c"))
+	c.Assert(sorted.PrevSibling(e), qt.Equals, m.FindDeep("f"))
+
+	c.Assert(sorted.NextSibling(&MenuEntry{}), qt.IsNil)
+	c.Assert(Menu(nil).PrevSibling(a), qt.IsNil)
+}
+
+func TestMenuFirstActiveAncestor(t *testing.T) {
+	c := qt.New(t)
+
+	p := newTestPage("/docs/p1")
+	m := createTreeTestMenu()
+
+	c.Assert(m.FirstActiveAncestor(p), qt.IsNil)
+
+	m.FindDeep("a11").Page = p
+	c.Assert(m.FirstActiveAncestor(p), qt.Equals, m[0])
+
+	m.FindDeep("a11").Page = nil
+	m.FindDeep("b").Page = p
+	c.Assert(m.FirstActiveAncestor(p), qt.Equals, m[1])
+}
+
+func TestMenuPrune(t *testing.T) {
+	c := qt.New(t)
+
+	m := createTreeTestMenu()
+
+	c.Assert(menuKeyNames(m.Prune(0).Flatten()), qt.DeepEquals, []string{"a", "b"})
+	c.Assert(menuKeyNames(m.Prune(1).Flatten()), qt.DeepEquals, []string{"a", "a1", "a2", "b"})
+	c.Assert(menuKeyNames(m.Prune(2).Flatten()), qt.DeepEquals, []string{"a", "a1", "a11", "a2", "b"})
+	c.Assert(m.Prune(2).Equals(m), qt.IsTrue)
+	c.Assert(m.Prune(0)[0].HasChildren(), qt.IsFalse)
+	c.Assert(m.Count(), qt.Equals, 5)
+}
+
+func TestMenuSortRecursive(t *testing.T) {
+	c := qt.New(t)
+
+	m := createTreeTestMenu()
+	a := m.FindDeep("a")
+	a.Weight = 2
+	m.FindDeep("b").Weight = 1
+	m.FindDeep("a1").Weight = 2
+	m.FindDeep("a2").Weight = 1
+
+	sorted := m.SortRecursive()
+	c.Assert(menuKeyNames(sorted.Flatten()), qt.DeepEquals, []string{"b", "a", "a2", "a1", "a11"})
+	c.Assert(sorted.FindDeep("a2").ParentEntry(), qt.Equals, sorted.FindDeep("a"))
+	c.Assert(sorted.FindDeep("a2").NextSibling(), qt.Equals, sorted.FindDeep("a1"))
+
+	// m is left untouched.
+	c.Assert(menuKeyNames(m.Flatten()), qt.DeepEquals, []string{"a", "a1", "a11", "a2", "b"})
+	c.Assert(sorted.FindDeep("a"), qt.Not(qt.Equals), a)
+
+	// Safe to use concurrently on a shared menu.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				c.Check(menuKeyNames(m.SortRecursive().Flatten()), qt.DeepEquals, []string{"b", "a", "a2", "a1", "a11"})
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestMenuEquals(t *testing.T) {
+	c := qt.New(t)
+
+	c.Assert(createTreeTestMenu().Equals(createTreeTestMenu()), qt.IsTrue)
+	c.Assert(Menu(nil).Equals(Menu{}), qt.IsTrue)
+
+	m := createTreeTestMenu()
+	m.FindDeep("a11").Identifier = "a12"
+	c.Assert(m.Equals(createTreeTestMenu()), qt.IsFalse)
+
+	m = createTreeTestMenu()
+	m.FindDeep("a1").Children = nil
+	c.Assert(m.Equals(createTreeTestMenu()), qt.IsFalse)
+
+	c.Assert(createTreeTestMenu().Reverse().Equals(createTreeTestMenu()), qt.IsFalse)
+}
+
+func TestMenuCloneDeep(t *testing.T) {
+	c := qt.New(t)
+
+	m := createTreeTestMenu()
+	m.FindDeep("a1").Params = maps.Params{"foo": "bar", "nested": maps.Params{"a": 1}}
+
+	clone := m.CloneDeep()
+	c.Assert(clone.Equals(m), qt.IsTrue)
+
+	a1 := clone.FindDeep("a1")
+	c.Assert(a1, qt.Not(qt.Equals), m.FindDeep("a1"))
+	c.Assert(a1.Depth(), qt.Equals, 1)
+	a1.Params["foo"] = "baz"
+	a1.Params["nested"].(maps.Params)["a"] = 2
+	a1.Children[0].Name = "changed"
+
+	c.Assert(m.FindDeep("a1").Params["foo"], qt.Equals, "bar")
+	c.Assert(m.FindDeep("a1").Params["nested"].(maps.Params)["a"], qt.Equals, 1)
+	c.Assert(m.FindDeep("a11").Name, qt.Equals, "")
+}
+
+func TestMenuInheritParams(t *testing.T) {
+	c := qt.New(t)
+
+	m := createTreeTestMenu()
+	m.FindDeep("a").Params = maps.Params{"section": "docs", "icon": "book"}
+	m.FindDeep("a1").Params = maps.Params{"icon": "page"}
+
+	inherited := m.InheritParams()
+
+	c.Assert(inherited.FindDeep("a").Params, qt.DeepEquals, maps.Params{"section": "docs", "icon": "book"})
+	c.Assert(inherited.FindDeep("a1").Params, qt.DeepEquals, maps.Params{"section": "docs", "icon": "page"})
+	c.Assert(inherited.FindDeep("a11").Params, qt.DeepEquals, maps.Params{"section": "docs", "icon": "page"})
+	c.Assert(inherited.FindDeep("a2").Params, qt.DeepEquals, maps.Params{"section": "docs", "icon": "book"})
+	c.Assert(inherited.FindDeep("b").Params, qt.IsNil)
+
+	c.Assert(m.FindDeep("a1").Params, qt.DeepEquals, maps.Params{"icon": "page"})
+	c.Assert(m.FindDeep("a11").Params, qt.IsNil)
+}
+
+func TestMenuInheritParamsShared(t *testing.T) {
+	c := qt.New(t)
+
+	// The same entry in two different lists is kept in both,
+	// with the params of each parent.
+	s := &MenuEntry{Identifier: "s", Params: maps.Params{"icon": "page"}}
+	m := Menu{
+		{Identifier: "a", Params: maps.Params{"section": "a"}, Children: Menu{s}},
+		{Identifier: "b", Params: maps.Params{"section": "b"}, Children: Menu{s}},
+	}
+	inherited := m.InheritParams()
+	c.Assert(menuKeyNames(inherited[0].Children), qt.DeepEquals, []string{"s"})
+	c.Assert(menuKeyNames(inherited[1].Children), qt.DeepEquals, []string{"s"})
+	c.Assert(inherited[0].Children[0].Params, qt.DeepEquals, maps.Params{"section": "a", "icon": "page"})
+	c.Assert(inherited[1].Children[0].Params, qt.DeepEquals, maps.Params{"section": "b", "icon": "page"})
+	c.Assert(s.Params, qt.DeepEquals, maps.Params{"icon": "page"})
+
+	// Cycles are left out.
+	m = createTreeTestMenu()
+	m.FindDeep("a11").Children = Menu{m[0]}
+	c.Assert(m.InheritParams()[0].Children[0].Children[0].HasChildren(), qt.IsFalse)
+}
+
+func TestMenuTree(t *testing.T) {
+	c := qt.New(t)
+
+	m := createTreeTestMenu()
+	m[0].Weight = 10
+	m[0].ConfiguredURL = "/a/"
+
+	c.Assert(m.Tree(), qt.Equals, `a|weight:10|url:/a/
+  a1|weight:0|url:
+    a11|weight:0|url:
+  a2|weight:0|url:
+b|weight:0|url:
+`)
+}
+
+func TestMenuActiveTrail(t *testing.T) {
+	c := qt.New(t)
+
+	p := newTestPage("/docs/p1")
+	m := createTreeTestMenu()
+	a11 := m.FindDeep("a11")
+	a11.Page = p
+
+	trail, err := m.ActiveTrail(p)
+	c.Assert(err, qt.IsNil)
+	c.Assert(menuKeyNames(trail), qt.DeepEquals, []string{"a", "a1", "a11"})
+
+	a11.Parent = "a2"
+	_, err = m.ActiveTrail(p)
+	c.Assert(err, qt.ErrorMatches, `menu entry "a11": parent "a2" does not match the entry it is placed below, "a1"`)
+
+	m = Menu{{Identifier: "b", Parent: "c", Page: p}}
+	_, err = m.ActiveTrail(p)
+	c.Assert(err, qt.ErrorMatches, `menu entry "b": parent "c" not found`)
+}
+
+func TestMenuEntryAriaCurrent(t *testing.T) {
+	c := qt.New(t)
+
+	p := newTestPage("/docs/p1")
+	m := createTreeTestMenu()
+	m.FindDeep("a1").Page = p
+
+	c.Assert(m.FindDeep("a1").AriaCurrent(p), qt.Equals, "page")
+	c.Assert(m.FindDeep("a").AriaCurrent(p), qt.Equals, "true")
+	c.Assert(m.FindDeep("a11").AriaCurrent(p), qt.Equals, "")
+	c.Assert(m.FindDeep("b").AriaCurrent(p), qt.Equals, "")
+	c.Assert(m.FindDeep("a1").AriaCurrent(nil), qt.Equals, "")
+}
+
+func TestMenuWalk(t *testing.T) {
+	c := qt.New(t)
+
+	m := createTreeTestMenu()
+
+	var visited []string
+	m.Walk(func(e *MenuEntry, depth int) bool {
+		visited = append(visited, fmt.Sprintf("%s:%d", e.KeyName(), depth))
+		return true
+	})
+	c.Assert(visited, qt.DeepEquals, []string{"a:0", "a1:1", "a11:2", "a2:1", "b:0"})
+
+	visited = nil
+	m.Walk(func(e *MenuEntry, depth int) bool {
+		visited = append(visited, e.KeyName())
+		return e.KeyName() != "a11"
+	})
+	c.Assert(visited, qt.DeepEquals, []string{"a", "a1", "a11"})
+}
@@ -0,0 +1,152 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package navigation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gohugoio/hugo/common/types"
+
+	"github.com/pkg/errors"
+)
+
+// Validate checks this menu entry for common configuration problems.
+// It is meant to be used on assembled menus, i.e. after any PageRef
+// has been resolved.
+func (m *MenuEntry) Validate() error {
+	if m.PageRef != "" && types.IsNil(m.Page) && m.ConfiguredURL == "" {
+		return errors.Errorf("menu entry %q: pageRef %q not found and no url set", m.KeyName(), m.PageRef)
+	}
+
+	if m.Name == "" && m.Title() == "" {
+		return errors.Errorf("menu entry %q: neither name nor title set", m.KeyName())
+	}
+
+	return nil
+}
+
+// Validate validates all the entries in the menu tree, see MenuEntry.Validate,
+// and checks that all parents referenced exist in the menu.
+// All problems found are reported in the returned error.
+func (m Menu) Validate() error {
+	var problems []string
+
+	keys := make(map[string]bool)
+	m.walk(func(me *MenuEntry, depth int) bool {
+		keys[me.KeyName()] = true
+		return true
+	})
+
+	m.walk(func(me *MenuEntry, depth int) bool {
+		if err := me.Validate(); err != nil {
+			problems = append(problems, err.Error())
+		}
+		if me.Parent != "" && !keys[me.Parent] {
+			problems = append(problems, errors.Errorf("menu entry %q: parent %q not found", me.KeyName(), me.Parent).Error())
+		}
+		return true
+	})
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+
+	return nil
+}
+
+// DetectCycles checks the parent relationships of the entries in the menu tree,
+// set up by the Parent identifiers, for cycles.
+// All cycles found are reported in the returned error with the
+// identifiers involved.
+func (m Menu) DetectCycles() error {
+	var keys []string
+	parents := make(map[string]string)
+	m.walk(func(me *MenuEntry, depth int) bool {
+		key := me.KeyName()
+		if _, found := parents[key]; !found {
+			keys = append(keys, key)
+		}
+		parents[key] = me.Parent
+		return true
+	})
+
+	var problems []string
+	reported := make(map[string]bool)
+
+	for _, key := range keys {
+		var path []string
+		positions := make(map[string]int)
+		for current := key; current != "" && !reported[current]; current = parents[current] {
+			if i, found := positions[current]; found {
+				cycle := append(path[i:], current)
+				for _, id := range path[i:] {
+					reported[id] = true
+				}
+				problems = append(problems, fmt.Sprintf("menu entry parent cycle detected: %s", strings.Join(cycle, " -> ")))
+				break
+			}
+			positions[current] = len(path)
+			path = append(path, current)
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+
+	return nil
+}
+
+// CheckUniqueIdentifiers checks that no two entries in the menu tree share
+// the same identifier (see MenuEntry.KeyName), including entries on
+// different levels.
+// One error is returned for each duplicated identifier, with the names
+// of the entries involved.
+func (m Menu) CheckUniqueIdentifiers() []error {
+	var keys []string
+	names := make(map[string][]string)
+	m.walk(func(me *MenuEntry, depth int) bool {
+		key := me.KeyName()
+		if _, found := names[key]; !found {
+			keys = append(keys, key)
+		}
+		names[key] = append(names[key], fmt.Sprintf("%q", me.Name))
+		return true
+	})
+
+	var errs []error
+	for _, key := range keys {
+		if len(names[key]) > 1 {
+			errs = append(errs, errors.Errorf("menu entry identifier %q is used by %d entries with names %s", key, len(names[key]), strings.Join(names[key], ", ")))
+		}
+	}
+
+	return errs
+}
+
+// CheckWeightRange checks that the weights of the entries in the menu tree
+// are within the range [minWeight, maxWeight].
+// Entries with a zero weight, i.e. no weight set, are not checked.
+// One error is returned for each entry outside the range.
+func (m Menu) CheckWeightRange(minWeight, maxWeight int) []error {
+	var errs []error
+	m.walk(func(me *MenuEntry, depth int) bool {
+		if me.Weight != 0 && (me.Weight < minWeight || me.Weight > maxWeight) {
+			errs = append(errs, errors.Errorf("menu entry %q: weight %d outside of range [%d, %d]", me.KeyName(), me.Weight, minWeight, maxWeight))
+		}
+		return true
+	})
+	return errs
+}
@@ -0,0 +1,102 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package navigation
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestMenuValidate(t *testing.T) {
+	c := qt.New(t)
+
+	c.Assert((&MenuEntry{Name: "A"}).Validate(), qt.IsNil)
+	c.Assert((&MenuEntry{title: "A"}).Validate(), qt.IsNil)
+	c.Assert((&MenuEntry{Page: newTestPage("/docs")}).Validate(), qt.IsNil)
+	c.Assert((&MenuEntry{Name: "A", PageRef: "/docs", ConfiguredURL: "/docs/"}).Validate(), qt.IsNil)
+	c.Assert((&MenuEntry{Name: "A", PageRef: "/docs"}).Validate(), qt.ErrorMatches, `menu entry "A": pageRef "/docs" not found and no url set`)
+	c.Assert((&MenuEntry{Identifier: "a"}).Validate(), qt.ErrorMatches, `menu entry "a": neither name nor title set`)
+
+	m := Menu{
+		{Name: "A", Children: Menu{{Name: "A1", Parent: "A"}}},
+		{Name: "B", Parent: "C"},
+		{Identifier: "d"},
+	}
+
+	c.Assert(m.Validate(), qt.ErrorMatches, `menu entry "B": parent "C" not found\nmenu entry "d": neither name nor title set`)
+	c.Assert(m[:1].Validate(), qt.IsNil)
+}
+
+func TestMenuDetectCycles(t *testing.T) {
+	c := qt.New(t)
+
+	m := Menu{
+		{Identifier: "a"},
+		{Identifier: "b", Parent: "a"},
+		{Identifier: "c", Parent: "b"},
+	}
+	c.Assert(m.DetectCycles(), qt.IsNil)
+	c.Assert(createTreeTestMenu().DetectCycles(), qt.IsNil)
+
+	m = Menu{
+		{Identifier: "a", Parent: "c"},
+		{Identifier: "b", Parent: "a"},
+		{Identifier: "c", Parent: "b"},
+		{Identifier: "d", Parent: "a"},
+		{Identifier: "e", Parent: "e"},
+	}
+	c.Assert(m.DetectCycles(), qt.ErrorMatches, "menu entry parent cycle detected: a -> c -> b -> a\nmenu entry parent cycle detected: e -> e")
+}
+
+func TestMenuCheckUniqueIdentifiers(t *testing.T) {
+	c := qt.New(t)
+
+	m := Menu{
+		{Identifier: "a", Name: "A", Children: Menu{
+			{Identifier: "b", Name: "B child"},
+		}},
+		{Identifier: "b", Name: "B"},
+		{Name: "C"},
+		{Name: "C"},
+		{Identifier: "d", Name: "D"},
+	}
+
+	errs := m.CheckUniqueIdentifiers()
+	c.Assert(errs, qt.HasLen, 2)
+	c.Assert(errs[0], qt.ErrorMatches, `menu entry identifier "b" is used by 2 entries with names "B child", "B"`)
+	c.Assert(errs[1], qt.ErrorMatches, `menu entry identifier "C" is used by 2 entries with names "C", "C"`)
+
+	c.Assert(createTreeTestMenu().CheckUniqueIdentifiers(), qt.IsNil)
+}
+
+func TestMenuCheckWeightRange(t *testing.T) {
+	c := qt.New(t)
+
+	m := Menu{
+		{Identifier: "a", Weight: 10, Children: Menu{
+			{Identifier: "a1", Weight: 10000},
+		}},
+		{Identifier: "b"},
+		{Identifier: "c", Weight: -5},
+		{Identifier: "d", Weight: 100},
+	}
+
+	errs := m.CheckWeightRange(1, 100)
+	c.Assert(errs, qt.HasLen, 2)
+	c.Assert(errs[0], qt.ErrorMatches, `menu entry "a1": weight 10000 outside of range \[1, 100\]`)
+	c.Assert(errs[1], qt.ErrorMatches, `menu entry "c": weight -5 outside of range \[1, 100\]`)
+
+	c.Assert(m.CheckWeightRange(-10, 10000), qt.IsNil)
+}
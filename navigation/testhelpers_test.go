@@ -0,0 +1,107 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package navigation
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gohugoio/hugo/common/maps"
+)
+
+var _ Page = (*testPage)(nil)
+
+// newTestPage creates a test page for the given content path, e.g. "/docs/intro".
+// Pages with only one path element are sections.
+func newTestPage(path string) *testPage {
+	section := strings.Split(strings.TrimPrefix(path, "/"), "/")[0]
+	return &testPage{
+		path:      path,
+		section:   section,
+		isSection: strings.Count(path, "/") == 1,
+		params:    make(maps.Params),
+	}
+}
+
+type testPage struct {
+	linkTitle string
+	path      string
+	section   string
+	weight    int
+	isSection bool
+	params    maps.Params
+	date      time.Time
+}
+
+func (p *testPage) LinkTitle() string {
+	if p.linkTitle != "" {
+		return p.linkTitle
+	}
+	return p.path
+}
+
+func (p *testPage) RelPermalink() string {
+	return p.path + "/"
+}
+
+func (p *testPage) Permalink() string {
+	return "https://example.org" + p.RelPermalink()
+}
+
+func (p *testPage) Path() string {
+	return p.path
+}
+
+func (p *testPage) Section() string {
+	return p.section
+}
+
+func (p *testPage) Weight() int {
+	return p.weight
+}
+
+func (p *testPage) IsPage() bool {
+	return !p.isSection
+}
+
+func (p *testPage) IsSection() bool {
+	return p.isSection
+}
+
+func (p *testPage) IsAncestor(other any) (bool, error) {
+	op, ok := other.(*testPage)
+	if !ok || op == nil || op == p {
+		return false, nil
+	}
+	return strings.HasPrefix(op.path, p.path+"/"), nil
+}
+
+func (p *testPage) Params() maps.Params {
+	return p.params
+}
+
+func (p *testPage) Date() time.Time {
+	return p.date
+}
+
+// cachedEntries returns the number of menus in the sort cache.
+func cachedEntries() int {
+	smc.RLock()
+	defer smc.RUnlock()
+	n := 0
+	for _, entries := range smc.m {
+		n += len(entries)
+	}
+	return n
+}